@@ -0,0 +1,446 @@
+package cache
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/redis"
+	"github.com/sk-pkg/cache/stats"
+)
+
+const (
+	// DefaultBreakerThreshold is the default number of consecutive failures that opens the breaker
+	DefaultBreakerThreshold = 5
+	// DefaultBreakerProbeInterval is the default interval between two health probes of an open breaker
+	DefaultBreakerProbeInterval = 5 * time.Second
+)
+
+// BreakerState describes whether a Breaker routes calls to its primary or fallback cache.
+type BreakerState int32
+
+const (
+	// BreakerClosed means calls go to the primary cache
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means the primary cache is unhealthy and calls go to the fallback cache
+	BreakerOpen
+)
+
+// String returns the human readable name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig holds the circuit breaker configuration parameters.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures that opens the breaker
+	// (defaults to DefaultBreakerThreshold)
+	Threshold int
+	// ProbeInterval is how often the primary cache is probed while the breaker is open
+	// (defaults to DefaultBreakerProbeInterval)
+	ProbeInterval time.Duration
+	// IsFailure reports whether an error counts as a failure of the primary cache
+	// (defaults to redis.IsUnavailable, so cache misses and bad values never trip the breaker)
+	IsFailure func(err error) bool
+	// Probe checks the health of the primary cache while the breaker is open
+	// (defaults to the primary's Ping method when it has one)
+	Probe func() error
+	// OnStateChange is called after every state transition
+	OnStateChange func(from, to BreakerState)
+
+	// log writes state transitions and swallowed errors, set by New from WithLogger
+	log *logx.Logger
+}
+
+// pinger is implemented by cache drivers that can check the health of their backend.
+type pinger interface {
+	Ping() error
+}
+
+// existsCache is implemented by cache drivers that report errors when checking for a key.
+type existsCache interface {
+	Exists(key string) (bool, error)
+}
+
+// Breaker is a circuit breaker around a primary cache, typically Redis.
+// After Threshold consecutive failures it routes all reads and writes to the
+// fallback cache, probes the primary every ProbeInterval, and switches back
+// as soon as a probe succeeds.
+//
+// Items written while the breaker is open only live in the fallback cache and
+// are not copied back to the primary cache on recovery.
+type Breaker struct {
+	primary  Cache
+	fallback Cache
+	cfg      BreakerConfig
+
+	state    atomic.Int32  // Current BreakerState
+	failures atomic.Int64  // Consecutive failures of the primary cache
+	mu       sync.Mutex    // Serializes state transitions
+	done     chan struct{} // Closed by Close to stop probing
+	stop     sync.Once     // Guards closing done
+}
+
+// NewBreaker creates a circuit breaker that uses primary while it is healthy
+// and fallback while it is not.
+//
+// Parameters:
+//   - primary: The cache used while the breaker is closed
+//   - fallback: The cache used while the breaker is open
+//   - cfg: The breaker configuration, zero fields use their defaults
+//
+// Returns:
+//   - *Breaker: The initialized circuit breaker, which itself implements Cache
+//
+// Example:
+//
+//	b := cache.NewBreaker(redisCache, mem.Init(), cache.BreakerConfig{
+//	  Threshold: 3,
+//	  OnStateChange: func(from, to cache.BreakerState) {
+//	    log.Printf("redis breaker %s -> %s", from, to)
+//	  },
+//	})
+func NewBreaker(primary, fallback Cache, cfg BreakerConfig) *Breaker {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = DefaultBreakerThreshold
+	}
+
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = DefaultBreakerProbeInterval
+	}
+
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = redis.IsUnavailable
+	}
+
+	if cfg.Probe == nil {
+		if p, ok := primary.(pinger); ok {
+			cfg.Probe = p.Ping
+		} else {
+			// Without a dedicated health check, a lookup that does not fail is good enough
+			cfg.Probe = func() error {
+				return probeLookup(primary, cfg.IsFailure)
+			}
+		}
+	}
+
+	return &Breaker{primary: primary, fallback: fallback, cfg: cfg, done: make(chan struct{})}
+}
+
+// probeLookup checks the health of c with a lookup. Errors that isFailure does
+// not count, such as cache misses, leave it healthy.
+func probeLookup(c Cache, isFailure func(err error) bool) error {
+	_, err := c.Get("breaker:probe")
+	if isFailure(err) {
+		return err
+	}
+	return nil
+}
+
+// Close stops probing the primary cache. The breaker keeps routing calls in its
+// current state; the primary and fallback caches belong to the caller and are
+// not closed. Closing a breaker more than once is a no-op.
+//
+// Returns:
+//   - error: Always nil (for io.Closer compatibility)
+//
+// Example:
+//
+//	b := cache.NewBreaker(redisCache, memCache, cache.BreakerConfig{})
+//	defer b.Close()
+func (b *Breaker) Close() error {
+	b.stop.Do(func() {
+		close(b.done)
+	})
+
+	return nil
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	return BreakerState(b.state.Load())
+}
+
+// do runs fn against the cache selected by the current state and records
+// the outcome when the primary cache was used.
+func (b *Breaker) do(fn func(c Cache) error) error {
+	if b.State() == BreakerOpen {
+		return fn(b.fallback)
+	}
+
+	err := fn(b.primary)
+	b.record(err)
+
+	return err
+}
+
+// record updates the failure counter and opens the breaker once the threshold is reached.
+func (b *Breaker) record(err error) {
+	if !b.cfg.IsFailure(err) {
+		b.failures.Store(0)
+		return
+	}
+
+	if b.failures.Add(1) >= int64(b.cfg.Threshold) {
+		if b.transition(BreakerClosed, BreakerOpen) {
+			go b.probe()
+		}
+	}
+}
+
+// transition moves the breaker from one state to another.
+// It returns false if the breaker was not in the expected state.
+func (b *Breaker) transition(from, to BreakerState) bool {
+	b.mu.Lock()
+	if b.State() != from {
+		b.mu.Unlock()
+		return false
+	}
+	b.state.Store(int32(to))
+	b.failures.Store(0)
+	b.mu.Unlock()
+
+	b.cfg.log.State("cache circuit breaker "+to.String(),
+		slog.String("from", from.String()),
+		slog.String("to", to.String()),
+	)
+
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(from, to)
+	}
+
+	return true
+}
+
+// probe checks the primary cache every ProbeInterval and closes the breaker
+// as soon as it is healthy again, or until the breaker is closed.
+func (b *Breaker) probe() {
+	ticker := time.NewTicker(b.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			if b.cfg.Probe() == nil {
+				b.transition(BreakerOpen, BreakerClosed)
+				return
+			}
+		}
+	}
+}
+
+// Put stores data in the currently active cache.
+func (b *Breaker) Put(key string, value any, seconds int) error {
+	return b.do(func(c Cache) error {
+		return c.Put(key, value, seconds)
+	})
+}
+
+// PutWithJitter stores data in the currently active cache with the given TTL jitter.
+func (b *Breaker) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	return b.do(func(c Cache) error {
+		if jc, ok := c.(jitterCache); ok {
+			return jc.PutWithJitter(key, value, seconds, j)
+		}
+		return c.Put(key, value, seconds)
+	})
+}
+
+// PutSlidingWithLifetime stores data in the currently active cache with a
+// sliding expiration, capped at lifetime.
+func (b *Breaker) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	return b.do(func(c Cache) error {
+		return putSliding(c, key, value, idle, lifetime)
+	})
+}
+
+// Add stores data in the currently active cache only if the key does not already exist.
+func (b *Breaker) Add(key string, value any, seconds int) error {
+	return b.do(func(c Cache) error {
+		return c.Add(key, value, seconds)
+	})
+}
+
+// AddWithJitter stores data in the currently active cache only if the key does
+// not already exist, with the given TTL jitter.
+func (b *Breaker) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	return b.do(func(c Cache) error {
+		if jc, ok := c.(jitterCache); ok {
+			return jc.AddWithJitter(key, value, seconds, j)
+		}
+		return c.Add(key, value, seconds)
+	})
+}
+
+// TryAdd stores data in the currently active cache only if the key does not
+// already exist, and reports whether it was stored.
+func (b *Breaker) TryAdd(key string, value any, seconds int) (bool, error) {
+	var added bool
+	err := b.do(func(c Cache) (err error) {
+		added, err = tryAdd(c, key, value, seconds, nil)
+		return err
+	})
+
+	return added, err
+}
+
+// TryAddWithJitter stores data in the currently active cache only if the key
+// does not already exist, with the given TTL jitter, and reports whether it was stored.
+func (b *Breaker) TryAddWithJitter(key string, value any, seconds int, j Jitter) (bool, error) {
+	var added bool
+	err := b.do(func(c Cache) (err error) {
+		added, err = tryAdd(c, key, value, seconds, &j)
+		return err
+	})
+
+	return added, err
+}
+
+// Get retrieves data from the currently active cache.
+func (b *Breaker) Get(key string) (any, error) {
+	var value any
+	err := b.do(func(c Cache) (err error) {
+		value, err = c.Get(key)
+		return err
+	})
+
+	return value, err
+}
+
+// Pull retrieves data from the currently active cache and then removes it.
+func (b *Breaker) Pull(key string) (any, error) {
+	var value any
+	err := b.do(func(c Cache) (err error) {
+		value, err = c.Pull(key)
+		return err
+	})
+
+	return value, err
+}
+
+// Has checks if an item exists in the currently active cache.
+// Errors of caches implementing Exists count as failures instead of being swallowed.
+func (b *Breaker) Has(key string) bool {
+	var exists bool
+	err := b.do(func(c Cache) (err error) {
+		if ec, ok := c.(existsCache); ok {
+			exists, err = ec.Exists(key)
+			return err
+		}
+		exists = c.Has(key)
+		return nil
+	})
+	if err != nil {
+		b.cfg.log.Error("cache lookup failed", err,
+			slog.String("operation", stats.OpHas.String()),
+			slog.String("key", key),
+		)
+	}
+
+	return exists
+}
+
+// Forever stores data permanently in the currently active cache.
+func (b *Breaker) Forever(key string, value any) error {
+	return b.do(func(c Cache) error {
+		return c.Forever(key, value)
+	})
+}
+
+// Forget removes an item from the currently active cache.
+func (b *Breaker) Forget(key string) (bool, error) {
+	var removed bool
+	err := b.do(func(c Cache) (err error) {
+		removed, err = c.Forget(key)
+		return err
+	})
+
+	return removed, err
+}
+
+// Increment increases the integer value of a key in the currently active cache.
+func (b *Breaker) Increment(key string, n int) (int, error) {
+	var value int
+	err := b.do(func(c Cache) (err error) {
+		value, err = c.Increment(key, n)
+		return err
+	})
+
+	return value, err
+}
+
+// Decrement decreases the integer value of a key in the currently active cache.
+func (b *Breaker) Decrement(key string, n int) (int, error) {
+	var value int
+	err := b.do(func(c Cache) (err error) {
+		value, err = c.Decrement(key, n)
+		return err
+	})
+
+	return value, err
+}
+
+// Flush removes all items from the currently active cache.
+func (b *Breaker) Flush() error {
+	return b.do(func(c Cache) error {
+		return c.Flush()
+	})
+}
+
+// Keys returns the matching keys of the currently active cache.
+func (b *Breaker) Keys(pattern string) ([]string, error) {
+	var keys []string
+	err := b.do(func(c Cache) (err error) {
+		keys, err = c.Keys(pattern)
+		return err
+	})
+
+	return keys, err
+}
+
+// Scan runs one step of a key iteration over the currently active cache.
+// A cursor is only meaningful to the cache that returned it: an iteration that
+// spans a state transition may miss or repeat keys and should restart from 0.
+func (b *Breaker) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	var keys []string
+	var next uint64
+	err := b.do(func(c Cache) (err error) {
+		keys, next, err = c.Scan(cursor, pattern, count)
+		return err
+	})
+
+	return keys, next, err
+}
+
+// ForgetPrefix removes the keys starting with prefix from the currently active cache.
+func (b *Breaker) ForgetPrefix(prefix string) (int, error) {
+	var removed int
+	err := b.do(func(c Cache) (err error) {
+		removed, err = forgetPrefix(c, prefix)
+		return err
+	})
+
+	return removed, err
+}
+
+// ForgetPattern removes the keys matching pattern from the currently active cache.
+func (b *Breaker) ForgetPattern(pattern string) (int, error) {
+	var removed int
+	err := b.do(func(c Cache) (err error) {
+		removed, err = forgetPattern(c, pattern)
+		return err
+	})
+
+	return removed, err
+}
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/mem"
+)
+
+// flakyCache is a primary cache that fails with a connection error while down.
+type flakyCache struct {
+	Cache
+	down atomic.Bool
+}
+
+var errConnRefused = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func (f *flakyCache) Put(key string, value any, seconds int) error {
+	if f.down.Load() {
+		return errConnRefused
+	}
+	return f.Cache.Put(key, value, seconds)
+}
+
+func (f *flakyCache) Get(key string) (any, error) {
+	if f.down.Load() {
+		return nil, errConnRefused
+	}
+	return f.Cache.Get(key)
+}
+
+func (f *flakyCache) Ping() error {
+	if f.down.Load() {
+		return errConnRefused
+	}
+	return nil
+}
+
+func TestBreaker(t *testing.T) {
+	primary := &flakyCache{Cache: mem.Init()}
+	fallback := mem.Init()
+
+	var mu sync.Mutex
+	var transitions []string
+	b := NewBreaker(primary, fallback, BreakerConfig{
+		Threshold:     3,
+		ProbeInterval: 10 * time.Millisecond,
+		OnStateChange: func(from, to BreakerState) {
+			mu.Lock()
+			transitions = append(transitions, from.String()+"->"+to.String())
+			mu.Unlock()
+		},
+	})
+
+	if err := b.Put("a", "primary", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	primary.down.Store(true)
+	for i := 0; i < 3; i++ {
+		if err := b.Put("a", "x", 0); err == nil {
+			t.Error("Put should report the primary failure before the breaker opens")
+		}
+	}
+
+	if b.State() != BreakerOpen {
+		t.Fatal("Breaker should be open after 3 failures, got:", b.State())
+	}
+
+	// Calls are now served by the fallback cache
+	if err := b.Put("b", "fallback", 0); err != nil {
+		t.Error("Put should succeed on the fallback cache:", err)
+	}
+
+	if v, _ := fallback.Get("b"); v != "fallback" {
+		t.Error("Put should have been routed to the fallback cache, got:", v)
+	}
+
+	primary.down.Store(false)
+	deadline := time.Now().Add(time.Second)
+	for b.State() != BreakerClosed && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if b.State() != BreakerClosed {
+		t.Fatal("Breaker should close once the probe succeeds")
+	}
+
+	if v, _ := b.Get("a"); v != "primary" {
+		t.Error("Get should be served by the primary cache again, got:", v)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transitions) != 2 || transitions[0] != "closed->open" || transitions[1] != "open->closed" {
+		t.Error("Unexpected state transitions:", transitions)
+	}
+}
+
+func TestBreakerIgnoresMisses(t *testing.T) {
+	b := NewBreaker(mem.Init(), mem.Init(), BreakerConfig{Threshold: 1})
+
+	// Errors that are not connection failures must not open the breaker
+	for i := 0; i < 5; i++ {
+		b.record(errors.New("redigo: nil returned"))
+	}
+
+	if b.State() != BreakerClosed {
+		t.Error("Breaker should stay closed on non-connection errors")
+	}
+}
+
+func TestBreakerLogging(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+
+	primary := &flakyCache{Cache: mem.Init()}
+	primary.down.Store(true)
+	b := NewBreaker(primary, mem.Init(), BreakerConfig{Threshold: 1, ProbeInterval: time.Hour, log: logx.New(logger, DefaultLogLevels(), 0)})
+
+	_ = b.Put("a", 1, 10)
+
+	if !strings.Contains(out.String(), "level=WARN msg=\"cache circuit breaker open\" from=closed to=open") {
+		t.Error("Expected the transition to be logged, got:", out.String())
+	}
+}
+
+func TestFallbackLogging(t *testing.T) {
+	var out bytes.Buffer
+	_, err := New(WithDefaultDriver(RedisCache), WithLogger(slog.New(slog.NewTextHandler(&out, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "falling back to memory cache") {
+		t.Error("Expected the fallback to be logged, got:", out.String())
+	}
+}
+
+// existsFlakyCache is a flakyCache reporting its lookup errors from Exists.
+type existsFlakyCache struct {
+	*flakyCache
+	pings atomic.Int32
+}
+
+func (f *existsFlakyCache) Exists(key string) (bool, error) {
+	if f.down.Load() {
+		return false, errConnRefused
+	}
+	return f.Has(key), nil
+}
+
+func (f *existsFlakyCache) Ping() error {
+	f.pings.Add(1)
+	return f.flakyCache.Ping()
+}
+
+func TestBreakerDecorated(t *testing.T) {
+	primary := &existsFlakyCache{flakyCache: &flakyCache{Cache: mem.Init()}}
+	b := NewBreaker(Decorator{Cache: primary}, mem.Init(), BreakerConfig{Threshold: 1, ProbeInterval: time.Hour})
+	defer b.Close()
+
+	// The probe goes through the Ping of the driver
+	if err := b.cfg.Probe(); err != nil || primary.pings.Load() != 1 {
+		t.Errorf("Expected the probe to ping the driver, got %v after %d pings", err, primary.pings.Load())
+	}
+
+	// Lookup errors of the driver count as failures
+	primary.down.Store(true)
+	_ = b.Has("a")
+	if b.State() != BreakerOpen {
+		t.Error("Expected the Exists error to open the breaker, got:", b.State())
+	}
+	if err := b.cfg.Probe(); !errors.Is(err, errConnRefused) {
+		t.Error("Expected the probe to fail while the driver is down, got:", err)
+	}
+
+	// A driver without Ping is probed with a lookup, where a miss is healthy
+	if err := (Decorator{Cache: mem.Init()}).Ping(); err != nil {
+		t.Error("Expected a decorated memory cache to be healthy, got:", err)
+	}
+
+	// Errors are classified like the default probe of the breaker
+	bad := &lookupErrorCache{Cache: mem.Init(), err: errors.New("invalid character")}
+	if err := (Decorator{Cache: bad}).Ping(); err != nil {
+		t.Error("Expected an error the breaker ignores to be healthy, got:", err)
+	}
+	bad.err = errConnRefused
+	if err := (Decorator{Cache: bad}).Ping(); !errors.Is(err, errConnRefused) {
+		t.Error("Expected a connection error to be unhealthy, got:", err)
+	}
+}
+
+// lookupErrorCache fails every Get with err and has no Ping method.
+type lookupErrorCache struct {
+	Cache
+	err error
+}
+
+func (c *lookupErrorCache) Get(string) (any, error) {
+	return nil, c.err
+}
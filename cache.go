@@ -4,8 +4,18 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/internal/lifecycle"
+	"github.com/sk-pkg/cache/internal/logx"
 	"github.com/sk-pkg/cache/mem"
 	"github.com/sk-pkg/cache/redis"
+	"github.com/sk-pkg/cache/stats"
 	redisManager "github.com/sk-pkg/redis"
 )
 
@@ -158,6 +168,147 @@ type Cache interface {
 	// Example:
 	//   err := cache.Flush()
 	Flush() error
+
+	// Keys returns the keys matching a Redis-style glob pattern ('*', '?',
+	// '[...]' and '\' escapes); an empty pattern matches every key.
+	// Drivers iterate incrementally, so the result is not a point-in-time view.
+	//
+	// Parameters:
+	//   - pattern: The glob pattern the keys must match
+	//
+	// Returns:
+	//   - []string: The matching keys, in no particular order
+	//   - error: Any error that occurred during the operation
+	//
+	// Example:
+	//   keys, err := cache.Keys("user:*")
+	Keys(pattern string) ([]string, error)
+
+	// Scan runs one step of an iteration over the keys matching a Redis-style
+	// glob pattern. Start with cursor 0 and pass the returned cursor to the next
+	// call until it is 0 again. count is a hint of the number of keys per step.
+	//
+	// Parameters:
+	//   - cursor: 0 to start an iteration, or the cursor returned by the previous call
+	//   - pattern: The glob pattern the keys must match, empty to match every key
+	//   - count: The number of keys to aim for (a driver default if not positive)
+	//
+	// Returns:
+	//   - []string: The matching keys found by this step
+	//   - uint64: The cursor of the next call, 0 when the iteration is complete
+	//   - error: Any error that occurred during the operation
+	//
+	// Example:
+	//   keys, cursor, err := cache.Scan(0, "session:*", 100)
+	Scan(cursor uint64, pattern string, count int) ([]string, uint64, error)
+}
+
+// Jitter describes how much random extra time is added to a TTL so that keys
+// written together do not all expire in the same second. See WithTTLJitter.
+type Jitter = mem.Jitter
+
+// jitterCache is implemented by cache drivers that support a per-call TTL jitter.
+type jitterCache interface {
+	PutWithJitter(key string, value any, seconds int, j Jitter) error
+	AddWithJitter(key string, value any, seconds int, j Jitter) error
+}
+
+// addCache is implemented by cache drivers that report whether Add stored the value.
+type addCache interface {
+	TryAdd(key string, value any, seconds int) (bool, error)
+	TryAddWithJitter(key string, value any, seconds int, j Jitter) (bool, error)
+}
+
+// tryAdd adds a value to c, with the jitter j unless it is nil, and reports
+// whether it was stored. Caches that cannot tell are checked with Has right
+// before the call, which concurrent writers can make wrong.
+func tryAdd(c Cache, key string, value any, seconds int, j *Jitter) (bool, error) {
+	if ac, ok := c.(addCache); ok {
+		if j == nil {
+			return ac.TryAdd(key, value, seconds)
+		}
+		return ac.TryAddWithJitter(key, value, seconds, *j)
+	}
+
+	existed := c.Has(key)
+	var err error
+	if jc, ok := c.(jitterCache); ok && j != nil {
+		err = jc.AddWithJitter(key, value, seconds, *j)
+	} else {
+		err = c.Add(key, value, seconds)
+	}
+	return err == nil && !existed, err
+}
+
+// slidingCache is implemented by cache drivers that extend the expiration of a
+// value every time it is read.
+type slidingCache interface {
+	PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error
+}
+
+// putSliding stores a value in c with a sliding expiration when c supports it,
+// and with Put and a fixed TTL otherwise.
+func putSliding(c Cache, key string, value any, idle, lifetime time.Duration) error {
+	if sc, ok := c.(slidingCache); ok {
+		return sc.PutSlidingWithLifetime(key, value, idle, lifetime)
+	}
+	return c.Put(key, value, slidingSeconds(idle, lifetime))
+}
+
+// slidingSeconds returns the initial TTL of a value with a sliding expiration,
+// the idle time capped at the lifetime, in seconds rounded up.
+func slidingSeconds(idle, lifetime time.Duration) int {
+	d := max(idle, 0)
+	if lifetime > 0 && (d == 0 || lifetime < d) {
+		d = lifetime
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// matchForgetter is implemented by cache drivers that remove the keys with a
+// prefix or matching a pattern in bulk.
+type matchForgetter interface {
+	ForgetPrefix(prefix string) (int, error)
+	ForgetPattern(pattern string) (int, error)
+}
+
+// forgetPrefix removes the keys of c starting with prefix, with a single call
+// when c supports it, and with Keys and Forget otherwise.
+func forgetPrefix(c Cache, prefix string) (int, error) {
+	if mf, ok := c.(matchForgetter); ok {
+		return mf.ForgetPrefix(prefix)
+	}
+	return forgetKeys(c, glob.Escape(prefix)+"*")
+}
+
+// forgetPattern removes the keys of c matching pattern, with a single call
+// when c supports it, and with Keys and Forget otherwise.
+func forgetPattern(c Cache, pattern string) (int, error) {
+	if mf, ok := c.(matchForgetter); ok {
+		return mf.ForgetPattern(pattern)
+	}
+	return forgetKeys(c, pattern)
+}
+
+// forgetKeys removes the keys of c matching pattern one by one.
+func forgetKeys(c Cache, pattern string) (int, error) {
+	keys, err := c.Keys(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, key := range keys {
+		ok, err := c.Forget(key)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed++
+		}
+	}
+
+	return removed, nil
 }
 
 // Manager provides a unified interface to work with different cache implementations.
@@ -167,10 +318,26 @@ type Manager struct {
 	Mem mem.Cache
 	// Redis is the Redis cache implementation
 	Redis *redis.Cache
+	// Breaker is the circuit breaker between Redis and Mem, nil unless enabled with WithCircuitBreaker
+	Breaker *Breaker
 	// defaultCache is the currently active cache implementation
 	defaultCache Cache
+	// driver is the identifier of the default cache driver, MemCache or RedisCache
+	driver string
+	// events delivers the activity of the cache drivers to listeners
+	events *Dispatcher
+	// stores holds the initialized drivers wrapped with the configured middlewares
+	stores map[string]Cache
+	// log writes structured logs, nil unless enabled with WithLogger
+	log *logx.Logger
+	// closed is set by Close
+	closed atomic.Bool
 }
 
+// ErrClosed is returned by the operations of a cache after Close.
+// The drivers return the same error, so errors.Is matches it whichever layer returned it.
+var ErrClosed = lifecycle.ErrClosed
+
 // Option is a function type used for configuring the cache manager.
 type Option func(*option)
 
@@ -180,6 +347,16 @@ type option struct {
 	prefix        string
 	redis         *redisManager.Manager
 	redisConfig   redis.Config
+	jitter        Jitter
+	breaker       *BreakerConfig
+	statsPrefix   stats.PrefixFunc
+	tracer        Tracer
+	middlewares   []Middleware
+	logger        *slog.Logger
+	logLevels     LogLevels
+	slowThreshold time.Duration
+	memOptions    []mem.Option
+	redisOptions  []redis.Option
 }
 
 // WithDefaultDriver sets the default cache driver to use.
@@ -256,6 +433,203 @@ func WithRedisConfig(redisConfig redis.Config) Option {
 	}
 }
 
+// WithTTLJitter sets the TTL jitter applied by both the memory and Redis drivers
+// to every Put and Add call. Use PutWithJitter or AddWithJitter to override it per call.
+//
+// Parameters:
+//   - j: The jitter settings; Fraction spreads expirations proportionally to the TTL,
+//     Max bounds the spread, and Rand replaces the random source (useful in tests)
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	cache.New(cache.WithTTLJitter(cache.Jitter{Fraction: 0.1, Max: time.Minute}))
+func WithTTLJitter(j Jitter) Option {
+	return func(o *option) {
+		o.jitter = j
+	}
+}
+
+// WithCircuitBreaker protects the Redis driver with a circuit breaker.
+// After cfg.Threshold consecutive connection failures, reads and writes of the
+// default driver go to the memory cache until Redis answers a probe again.
+// It only takes effect when Redis is the default driver.
+//
+// Parameters:
+//   - cfg: The breaker configuration, zero fields use their defaults
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	cache.New(
+//	  cache.WithDefaultDriver("redis"),
+//	  cache.WithRedisConfig(config),
+//	  cache.WithCircuitBreaker(cache.BreakerConfig{
+//	    Threshold:     3,
+//	    ProbeInterval: time.Second,
+//	    OnStateChange: func(from, to cache.BreakerState) {
+//	      alert("redis breaker " + to.String())
+//	    },
+//	  }),
+//	)
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(o *option) {
+		o.breaker = &cfg
+	}
+}
+
+// WithStatsPrefix additionally groups the statistics of every driver by key prefix.
+//
+// Parameters:
+//   - fn: Maps a key to its statistics group; keys mapped to "" are only counted in the totals
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	cache.New(cache.WithStatsPrefix(stats.PrefixUntil(":")))
+func WithStatsPrefix(fn stats.PrefixFunc) Option {
+	return func(o *option) {
+		o.statsPrefix = fn
+	}
+}
+
+// WithTracer reports every call of the default cache driver as a span to t.
+// Use Manager.WithContext to attach the spans to the caller's trace.
+//
+// Parameters:
+//   - t: The tracer receiving the spans, e.g. an AttributeTracer wrapping an OpenTelemetry tracer
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	c, _ := cache.New(cache.WithTracer(cache.AttributeTracer{Starter: starter}))
+//	value, err := c.WithContext(ctx).Get("user:1")
+func WithTracer(t Tracer) Option {
+	return func(o *option) {
+		o.tracer = t
+	}
+}
+
+// WithMemOptions passes options to the memory cache driver, e.g. to bound its size.
+// They are applied after the options derived from the manager configuration,
+// so mem.WithOnEvicted replaces the callback dispatching KeyEvicted events;
+// listen to the events instead.
+//
+// Parameters:
+//   - opts: The memory cache options
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	c, err := cache.New(cache.WithMemOptions(mem.WithMaxEntries(100_000)))
+func WithMemOptions(opts ...mem.Option) Option {
+	return func(o *option) {
+		o.memOptions = append(o.memOptions, opts...)
+	}
+}
+
+// WithRedisOptions passes options to the Redis cache driver, e.g. to pace its
+// bulk deletions. They are applied after the options derived from the manager
+// configuration, so they take precedence.
+//
+// Parameters:
+//   - opts: The Redis cache options
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	c, err := cache.New(
+//	  cache.WithRedisConfig(redis.Config{Address: "localhost:6379"}),
+//	  cache.WithRedisOptions(redis.WithBatchSize(500), redis.WithDeleteRate(50_000)),
+//	)
+func WithRedisOptions(opts ...redis.Option) Option {
+	return func(o *option) {
+		o.redisOptions = append(o.redisOptions, opts...)
+	}
+}
+
+// LogLevels holds the level each kind of log record is written at.
+// See WithLogLevels for details.
+type LogLevels = mem.LogLevels
+
+// DefaultLogLevels returns the log levels used unless WithLogLevels is given:
+// swallowed errors, driver fallback, circuit breaker transitions and slow
+// operations are warnings, janitor sweeps are debug records.
+func DefaultLogLevels() LogLevels {
+	return mem.DefaultLogLevels()
+}
+
+// WithLogger writes structured logs to l. It is passed on to every cache driver,
+// so swallowed errors, janitor sweeps, the fallback from Redis to the memory
+// cache, circuit breaker transitions and slow operations are logged.
+// Nothing is logged by default.
+//
+// Parameters:
+//   - l: The destination logger
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	c, err := cache.New(cache.WithLogger(slog.Default()))
+func WithLogger(l *slog.Logger) Option {
+	return func(o *option) {
+		o.logger = l
+	}
+}
+
+// WithLogLevels sets the level each kind of log record is written at
+// (defaults to DefaultLogLevels).
+//
+// Parameters:
+//   - levels: The level of every kind of record
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	levels := cache.DefaultLogLevels()
+//	levels.State = slog.LevelError
+//	c, err := cache.New(cache.WithLogger(logger), cache.WithLogLevels(levels))
+func WithLogLevels(levels LogLevels) Option {
+	return func(o *option) {
+		o.logLevels = levels
+	}
+}
+
+// WithSlowThreshold logs operations of every cache driver taking longer than d
+// (defaults to mem.DefaultSlowThreshold, 0 disables). The memory cache only checks
+// the operations it samples for its latency statistics.
+//
+// Parameters:
+//   - d: The duration above which an operation is slow
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	c, err := cache.New(cache.WithLogger(logger), cache.WithSlowThreshold(20*time.Millisecond))
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *option) {
+		o.slowThreshold = d
+	}
+}
+
 // Put stores data in the cache for a specified duration using the default cache driver.
 //
 // Parameters:
@@ -282,6 +656,84 @@ func (m *Manager) Add(key string, value any, seconds int) error {
 	return m.defaultCache.Add(key, value, seconds)
 }
 
+// PutWithJitter stores data like Put, but with the given jitter instead of the
+// one configured with WithTTLJitter. Drivers without jitter support fall back to Put.
+//
+// Parameters:
+//   - key: The unique identifier for the cached item
+//   - value: The data to be stored in the cache
+//   - seconds: The time-to-live in seconds (0 means no expiration)
+//   - j: The jitter applied to this TTL (a zero Jitter disables it)
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (m *Manager) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	if jc, ok := m.defaultCache.(jitterCache); ok {
+		return jc.PutWithJitter(key, value, seconds, j)
+	}
+
+	return m.defaultCache.Put(key, value, seconds)
+}
+
+// PutSliding stores data that expires once it has not been read for idle,
+// e.g. a session expiring after 30 minutes of inactivity. Every Get that finds
+// the data extends its expiration. Drivers without sliding expirations fall
+// back to Put with idle as TTL.
+//
+// Parameters:
+//   - key: The unique identifier for the cached item
+//   - value: The data to be stored in the cache
+//   - idle: How long the data lives without being read (0 means no expiration)
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+//
+// Example:
+//
+//	err := c.PutSliding("session:"+id, session, 30*time.Minute)
+func (m *Manager) PutSliding(key string, value any, idle time.Duration) error {
+	return putSliding(m.defaultCache, key, value, idle, 0)
+}
+
+// PutSlidingWithLifetime stores data like PutSliding, but never extends its
+// expiration past lifetime from now, however often it is read.
+//
+// Parameters:
+//   - key: The unique identifier for the cached item
+//   - value: The data to be stored in the cache
+//   - idle: How long the data lives without being read (0 means no expiration)
+//   - lifetime: The maximum time the data lives (0 means no limit)
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+//
+// Example:
+//
+//	// Log out after 30 minutes of inactivity, and after 12 hours in any case
+//	err := c.PutSlidingWithLifetime("session:"+id, session, 30*time.Minute, 12*time.Hour)
+func (m *Manager) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	return putSliding(m.defaultCache, key, value, idle, lifetime)
+}
+
+// AddWithJitter stores data like Add, but with the given jitter instead of the
+// one configured with WithTTLJitter. Drivers without jitter support fall back to Add.
+//
+// Parameters:
+//   - key: The unique identifier for the cached item
+//   - value: The data to be stored in the cache
+//   - seconds: The time-to-live in seconds (0 means no expiration)
+//   - j: The jitter applied to this TTL (a zero Jitter disables it)
+//
+// Returns:
+//   - error: Any error that occurred during the operation
+func (m *Manager) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	if jc, ok := m.defaultCache.(jitterCache); ok {
+		return jc.AddWithJitter(key, value, seconds, j)
+	}
+
+	return m.defaultCache.Add(key, value, seconds)
+}
+
 // Get retrieves data from the cache using the default cache driver.
 //
 // Parameters:
@@ -375,6 +827,212 @@ func (m *Manager) Flush() error {
 	return m.defaultCache.Flush()
 }
 
+// Keys returns the keys matching a Redis-style glob pattern using the default cache driver.
+//
+// Parameters:
+//   - pattern: The glob pattern the keys must match, empty to match every key
+//
+// Returns:
+//   - []string: The matching keys, in no particular order
+//   - error: Any error that occurred during the operation
+func (m *Manager) Keys(pattern string) ([]string, error) {
+	return m.defaultCache.Keys(pattern)
+}
+
+// Scan runs one step of an iteration over the keys matching a Redis-style glob
+// pattern using the default cache driver.
+//
+// Parameters:
+//   - cursor: 0 to start an iteration, or the cursor returned by the previous call
+//   - pattern: The glob pattern the keys must match, empty to match every key
+//   - count: The number of keys to aim for (a driver default if not positive)
+//
+// Returns:
+//   - []string: The matching keys found by this step
+//   - uint64: The cursor of the next call, 0 when the iteration is complete
+//   - error: Any error that occurred during the operation
+func (m *Manager) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	return m.defaultCache.Scan(cursor, pattern, count)
+}
+
+// ForgetPrefix removes every key starting with prefix using the default cache
+// driver, e.g. all the keys of a user, without flushing the rest of the cache.
+// Drivers without bulk removal have the keys listed with Keys and removed one by one.
+//
+// Parameters:
+//   - prefix: The prefix of the keys to remove, empty to remove every key
+//
+// Returns:
+//   - int: The number of keys removed
+//   - error: Any error that occurred during the operation
+//
+// Example:
+//
+//	removed, err := c.ForgetPrefix("user:42:")
+func (m *Manager) ForgetPrefix(prefix string) (int, error) {
+	return forgetPrefix(m.defaultCache, prefix)
+}
+
+// ForgetPattern removes every key matching a Redis-style glob pattern using
+// the default cache driver. Drivers without bulk removal have the keys listed
+// with Keys and removed one by one.
+//
+// Parameters:
+//   - pattern: The glob pattern of the keys to remove, empty to match every key
+//
+// Returns:
+//   - int: The number of keys removed
+//   - error: Any error that occurred during the operation
+//
+// Example:
+//
+//	removed, err := c.ForgetPattern("user:*:permissions")
+func (m *Manager) ForgetPattern(pattern string) (int, error) {
+	return forgetPattern(m.defaultCache, pattern)
+}
+
+// Close stops the background work of the manager and releases its resources:
+// the probing of the circuit breaker, the janitors of the memory cache, the
+// Redis connection pool created from WithRedisConfig (a manager given with
+// WithRedis is left open) and the goroutines of the async event listeners.
+// Afterwards every operation returns ErrClosed. Closing a manager more than
+// once is a no-op.
+//
+// Returns:
+//   - error: The errors encountered while closing the drivers, joined
+//
+// Example:
+//
+//	c, err := cache.New()
+//	if err != nil {
+//	  return err
+//	}
+//	defer c.Close()
+func (m *Manager) Close() error {
+	if !m.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	var errs []error
+	if m.Breaker != nil {
+		errs = append(errs, m.Breaker.Close())
+	}
+	errs = append(errs, m.Mem.Close())
+	if m.Redis != nil {
+		errs = append(errs, m.Redis.Close())
+	}
+	m.events.Close()
+
+	return errors.Join(errs...)
+}
+
+// WithContext returns the default cache driver bound to ctx, so that tracing
+// spans become children of the span in ctx. Without a tracer, the default
+// cache driver is returned as is.
+//
+// Parameters:
+//   - ctx: The context of the caller
+//
+// Returns:
+//   - Cache: The default cache driver bound to ctx
+//
+// Example:
+//
+//	value, err := c.WithContext(r.Context()).Get("user:1")
+func (m *Manager) WithContext(ctx context.Context) Cache {
+	if cb, ok := m.defaultCache.(contextBinder); ok {
+		return cb.WithContext(ctx)
+	}
+
+	return m.defaultCache
+}
+
+// Events returns the dispatcher of the cache events. Calls through the manager
+// dispatch CacheHit, CacheMissed, KeyWritten, KeyForgotten, KeysForgotten and Flushed, and the
+// memory cache dispatches KeyEvicted for items it removes on its own.
+//
+// Returns:
+//   - *Dispatcher: The event dispatcher to register listeners on
+//
+// Example:
+//
+//	cache.On(c.Events(), func(e cache.KeyWritten) {
+//	  if strings.HasPrefix(e.Key, "permission:") {
+//	    audit.Log("cache write", e.Key)
+//	  }
+//	})
+func (m *Manager) Events() *Dispatcher {
+	return m.events
+}
+
+// Stats returns the statistics of every initialized driver, keyed by driver
+// identifier (MemCache, RedisCache).
+//
+// Returns:
+//   - map[string]stats.Snapshot: Hits, misses, writes, deletes, errors, evictions
+//     and latency histograms per driver
+//
+// Example:
+//
+//	for driver, s := range c.Stats() {
+//	  fmt.Printf("%s hit ratio: %.2f\n", driver, s.HitRatio())
+//	}
+func (m *Manager) Stats() map[string]stats.Snapshot {
+	s := map[string]stats.Snapshot{MemCache: m.Mem.Stats()}
+	if m.Redis != nil {
+		s[RedisCache] = m.Redis.Stats()
+	}
+
+	return s
+}
+
+// Len returns the number of items of the default cache driver: the live items
+// of the memory cache, or the keys under the prefix in Redis, estimated from a
+// sample on large databases (see redis.Cache.Usage). The memory cache in use
+// while the circuit breaker is open is not counted.
+//
+// Returns:
+//   - int: The number of items
+//   - error: Any error that occurred while counting the Redis keys
+//
+// Example:
+//
+//	n, err := c.Len()
+func (m *Manager) Len() (int, error) {
+	if m.driver == RedisCache {
+		return m.Redis.Len()
+	}
+
+	return m.Mem.Len(), nil
+}
+
+// Usage returns how much every initialized driver holds, keyed by driver
+// identifier (MemCache, RedisCache): the items, the expired items not removed
+// yet and the estimated bytes. The usage of the memory cache is also part of Stats.
+//
+// Returns:
+//   - map[string]stats.Usage: The usage per driver, without RedisCache if it failed
+//   - error: Any error that occurred while sampling Redis
+//
+// Example:
+//
+//	usage, err := c.Usage()
+//	fmt.Printf("mem: %d items, ~%d bytes\n", usage[cache.MemCache].Items, usage[cache.MemCache].Bytes)
+func (m *Manager) Usage() (map[string]stats.Usage, error) {
+	u := map[string]stats.Usage{MemCache: m.Mem.Usage()}
+	if m.Redis == nil {
+		return u, nil
+	}
+
+	ru, err := m.Redis.Usage()
+	if err != nil {
+		return u, err
+	}
+	u[RedisCache] = ru
+
+	return u, nil
+}
+
 // New creates a new cache manager with the specified options.
 //
 // Parameters:
@@ -398,43 +1056,94 @@ func (m *Manager) Flush() error {
 //	)
 func New(opts ...Option) (*Manager, error) {
 	// Initialize options with default prefix
-	opt := &option{prefix: DefaultPrefix}
+	opt := &option{prefix: DefaultPrefix, logLevels: DefaultLogLevels(), slowThreshold: mem.DefaultSlowThreshold}
 
 	// Apply all provided option functions
 	for _, f := range opts {
 		f(opt)
 	}
 
-	manager := &Manager{}
+	manager := &Manager{events: NewDispatcher(), log: logx.New(opt.logger, opt.logLevels, opt.slowThreshold)}
 
 	// Initialize memory cache (always available)
-	manager.Mem = mem.Init()
+	memOpts := []mem.Option{
+		mem.WithTTLJitter(opt.jitter),
+		mem.WithLogger(opt.logger),
+		mem.WithLogLevels(opt.logLevels),
+		mem.WithSlowThreshold(opt.slowThreshold),
+		mem.WithOnEvicted(func(key string, value any, reason mem.EvictReason) {
+			// Replaced, deleted and flushed values are reported by KeyWritten, KeyForgotten, KeysForgotten and Flushed
+			if reason == mem.Expired || reason == mem.Evicted {
+				manager.events.Dispatch(KeyEvicted{Store: MemCache, Key: key, Value: value, Reason: reason})
+			}
+		}),
+	}
+	if opt.statsPrefix != nil {
+		memOpts = append(memOpts, mem.WithStatsPrefix(opt.statsPrefix))
+	}
+	memOpts = append(memOpts, opt.memOptions...)
+	manager.Mem = mem.Init(memOpts...)
 
 	// Initialize Redis cache if Redis configuration is provided
 	if opt.redis != nil || opt.redisConfig != (redis.Config{}) {
-		redisCache, err := redis.Init(
+		redisOpts := []redis.Option{
 			redis.WithPrefix(opt.prefix),
 			redis.WithRedisConfig(opt.redisConfig),
 			redis.WithRedisManager(opt.redis),
-		)
+			redis.WithTTLJitter(opt.jitter),
+			redis.WithLogger(opt.logger),
+			redis.WithLogLevels(opt.logLevels),
+			redis.WithSlowThreshold(opt.slowThreshold),
+		}
+		if opt.statsPrefix != nil {
+			redisOpts = append(redisOpts, redis.WithStatsPrefix(opt.statsPrefix))
+		}
+		redisOpts = append(redisOpts, opt.redisOptions...)
+
+		redisCache, err := redis.Init(redisOpts...)
 		if err != nil {
 			return nil, err
 		}
 		manager.Redis = redisCache
 	}
 
+	// Wrap every initialized driver with the configured middlewares
+	wrap := Chain(opt.middlewares...)
+	manager.stores = map[string]Cache{MemCache: wrap(manager.Mem)}
+	if manager.Redis != nil {
+		manager.stores[RedisCache] = wrap(manager.Redis)
+	}
+
 	// Set the default cache driver based on configuration
-	switch opt.defaultDriver {
-	case RedisCache:
+	driver := MemCache
+	manager.defaultCache = manager.stores[MemCache]
+	if opt.defaultDriver == RedisCache && manager.Redis != nil {
 		// Use Redis if available, otherwise fall back to memory cache
-		if manager.Redis != nil {
-			manager.defaultCache = manager.Redis
-		} else {
-			manager.defaultCache = manager.Mem
+		driver = RedisCache
+		manager.defaultCache = manager.stores[RedisCache]
+		// Route calls to memory cache while Redis is unavailable
+		if opt.breaker != nil {
+			cfg := *opt.breaker
+			cfg.log = manager.log
+			manager.Breaker = NewBreaker(manager.stores[RedisCache], manager.stores[MemCache], cfg)
+			manager.defaultCache = manager.Breaker
 		}
-	default:
-		// Use memory cache as the default
-		manager.defaultCache = manager.Mem
+	} else if opt.defaultDriver == RedisCache {
+		manager.log.State("redis cache not configured, falling back to memory cache",
+			slog.String("driver", RedisCache),
+		)
+	}
+
+	manager.driver = driver
+
+	// Dispatch the activity of the default cache driver as events
+	manager.defaultCache = &eventCache{next: manager.defaultCache, store: driver, events: manager.events}
+
+	// Report every call of the default cache driver to the tracer
+	if _, nop := opt.tracer.(NopTracer); opt.tracer != nil && !nop {
+		traced := Traced(manager.defaultCache, driver, opt.tracer)
+		traced.log = manager.log
+		manager.defaultCache = traced
 	}
 
 	return manager, nil
@@ -0,0 +1,198 @@
+package cache
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+	"github.com/sk-pkg/cache/mem"
+	"github.com/sk-pkg/cache/redis"
+)
+
+// goroutines returns the stack traces of the goroutines created by the
+// functions of this module. It waits up to a second for their number to drop
+// to at most n, so goroutines that are stopping have time to exit.
+func goroutines(n int) []string {
+	const creator = "created by github.com/sk-pkg/cache"
+
+	var found []string
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		buf := make([]byte, 1<<22)
+		buf = buf[:runtime.Stack(buf, true)]
+
+		found = found[:0]
+		for _, g := range strings.Split(string(buf), "\n\n") {
+			if strings.Contains(g, creator) {
+				found = append(found, g)
+			}
+		}
+		if len(found) <= n || time.Now().After(deadline) {
+			return found
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	// Goroutines left by other tests are not leaks of this one
+	running := len(goroutines(math.MaxInt))
+
+	// Nothing listens on the address, so the breaker opens and starts probing
+	c, err := New(
+		WithDefaultDriver(RedisCache),
+		WithRedisConfig(redis.Config{Address: "127.0.0.1:1"}),
+		WithCircuitBreaker(BreakerConfig{Threshold: 1, ProbeInterval: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal("New failed:", err)
+	}
+	received := make(chan Event, 1)
+	c.Events().ListenAsync(func(e Event) {
+		select {
+		case received <- e:
+		default:
+		}
+	})
+
+	_, _ = c.Get("a")
+	if c.Breaker.State() != BreakerOpen {
+		t.Fatal("Expected the breaker to open, got:", c.Breaker.State())
+	}
+	// The memory cache takes over, and its writes are dispatched
+	if err := c.Put("a", 1, 0); err != nil {
+		t.Fatal("Put failed:", err)
+	}
+	<-received
+
+	if err := c.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	if found := goroutines(running); len(found) > running {
+		t.Fatalf("%d goroutines still running after Close:\n%s", len(found)-running, strings.Join(found, "\n\n"))
+	}
+	if err := c.Close(); err != nil {
+		t.Error("Closing twice should be a no-op, got:", err)
+	}
+
+	if err := c.Put("a", 1, 0); !errors.Is(err, ErrClosed) {
+		t.Error("Expected Put to return ErrClosed, got:", err)
+	}
+	if _, err := c.Get("a"); !errors.Is(err, ErrClosed) {
+		t.Error("Expected Get to return ErrClosed, got:", err)
+	}
+	if _, err := c.Store(RedisCache).Forget("a"); !errors.Is(err, ErrClosed) {
+		t.Error("Expected the Redis driver to return ErrClosed, got:", err)
+	}
+	if c.Has("a") {
+		t.Error("Has should report false after Close")
+	}
+
+	// Listeners added after Close never run
+	c.Events().ListenAsync(func(Event) {})
+	if found := goroutines(running); len(found) > running {
+		t.Error("Expected no goroutine for a listener added after Close, got:", len(found)-running)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal("New failed:", err)
+	}
+	defer c.Close()
+
+	for _, key := range []string{"user:1", "user:2", "session:1"} {
+		_ = c.Put(key, key, 0)
+	}
+
+	keys, err := c.Keys("user:*")
+	slices.Sort(keys)
+	if err != nil || !slices.Equal(keys, []string{"user:1", "user:2"}) {
+		t.Error("Expected the user keys, got:", keys, err)
+	}
+
+	var scanned []string
+	var cursor uint64
+	for {
+		found, next, err := c.Scan(cursor, "", 1)
+		if err != nil {
+			t.Fatal("Scan failed:", err)
+		}
+		scanned = append(scanned, found...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if len(scanned) != 3 {
+		t.Error("Expected Scan to return every key once, got:", scanned)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal("New failed:", err)
+	}
+	defer c.Close()
+
+	for i := range 10 {
+		_ = c.Put("user:"+strconv.Itoa(i), i, 0)
+	}
+
+	if n, err := c.Len(); err != nil || n != 10 {
+		t.Errorf("Expected Len to return 10, got %d, %v", n, err)
+	}
+
+	usage, err := c.Usage()
+	if err != nil {
+		t.Fatal("Usage failed:", err)
+	}
+	if u := usage[MemCache]; u.Items != 10 || u.Bytes <= 0 || len(u.Shards) != mem.DefaultShards {
+		t.Error("Expected the usage of the memory cache, got:", u)
+	}
+	if _, ok := usage[RedisCache]; ok {
+		t.Error("Expected no Redis usage without Redis")
+	}
+	if s := c.Stats()[MemCache]; s.Usage.Items != 10 {
+		t.Error("Expected Stats to include the usage, got:", s.Usage)
+	}
+}
+
+func TestPutSliding(t *testing.T) {
+	// Through a middleware the data falls back to a fixed TTL
+	for _, mws := range [][]Middleware{nil, {func(next Cache) Cache { return Decorator{Cache: next} }}} {
+		clk := clocktest.NewFake(time.Unix(1000, 0))
+		c, err := New(WithMiddleware(mws...), WithMemOptions(mem.WithClock(clk), mem.WithJanitorInterval(0)))
+		if err != nil {
+			t.Fatal("New failed:", err)
+		}
+
+		var written []Event
+		On(c.Events(), func(e KeyWritten) {
+			written = append(written, e)
+		})
+
+		if err := c.PutSlidingWithLifetime("session", "token", 1500*time.Millisecond, time.Hour); err != nil {
+			t.Fatal("PutSlidingWithLifetime failed:", err)
+		}
+		want := []Event{KeyWritten{Store: MemCache, Key: "session", Value: "token", Seconds: 2}}
+		if !slices.Equal(written, want) {
+			t.Errorf("Expected %v, got %v", want, written)
+		}
+
+		for range 3 {
+			clk.Advance(time.Second)
+			_, _ = c.Get("session")
+		}
+		if sliding := mws == nil; c.Has("session") != sliding {
+			t.Errorf("Expected the session to be extended = %v", sliding)
+		}
+
+		_ = c.Close()
+	}
+}
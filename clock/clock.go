@@ -0,0 +1,51 @@
+// Package clock abstracts the passing of time for the cache drivers, so that
+// TTLs and background sweeps can be tested without sleeping.
+// See the clocktest package for a clock advanced by hand.
+package clock
+
+import "time"
+
+// Clock provides the current time and tickers.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTicker returns a Ticker sending the time on its channel every d.
+	// d must be greater than zero.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks at intervals, like time.Ticker.
+type Ticker interface {
+	// C returns the channel the ticks are delivered on.
+	C() <-chan time.Time
+	// Stop turns off the ticker. No more ticks are sent after Stop returns.
+	Stop()
+}
+
+// System is the Clock reading the system time. It is the default clock of the
+// cache drivers.
+var System Clock = systemClock{}
+
+// systemClock implements Clock with the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{t: time.NewTicker(d)}
+}
+
+// systemTicker implements Ticker with a time.Ticker.
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (s systemTicker) C() <-chan time.Time {
+	return s.t.C
+}
+
+func (s systemTicker) Stop() {
+	s.t.Stop()
+}
@@ -0,0 +1,133 @@
+// Package clocktest provides a clock.Clock advanced by hand, to test TTLs and
+// background sweeps deterministically.
+package clocktest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sk-pkg/cache/clock"
+)
+
+// Fake is a clock.Clock whose time only moves when Advance or Set is called.
+// Its tickers fire while the time is moved, like a time.Ticker: a tick that
+// is not received before the next one is dropped.
+//
+// Example:
+//
+//	clk := clocktest.NewFake(time.Now())
+//	c := mem.Init(mem.WithClock(clk))
+//	c.Put("session", token, 60)
+//	clk.Advance(61 * time.Second)
+//	v, _ := c.Get("session") // nil, the item has expired
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers map[*ticker]struct{}
+}
+
+// NewFake creates a Fake clock set to now.
+//
+// Parameters:
+//   - now: The initial time of the clock
+//
+// Returns:
+//   - *Fake: The fake clock
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, tickers: make(map[*ticker]struct{})}
+}
+
+// Now returns the current time of the clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// NewTicker returns a Ticker firing every d of clock time.
+// It panics if d is not greater than zero, like time.NewTicker.
+func (f *Fake) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &ticker{fake: f, c: make(chan time.Time, 1), d: d, next: f.now.Add(d)}
+	f.tickers[t] = struct{}{}
+
+	return t
+}
+
+// Advance moves the clock forward by d and fires the tickers that are due.
+//
+// Parameters:
+//   - d: The duration to move the clock by
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.set(f.now.Add(d))
+}
+
+// Set moves the clock to t and fires the tickers that are due. Moving the
+// clock backwards fires no ticker.
+//
+// Parameters:
+//   - t: The new time of the clock
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.set(t)
+}
+
+// Tickers returns the number of tickers that were created and not stopped,
+// e.g. to wait until a background goroutine has started its ticker.
+func (f *Fake) Tickers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.tickers)
+}
+
+// set moves the clock to now and fires the tickers that are due.
+// The caller must hold the lock.
+func (f *Fake) set(now time.Time) {
+	f.now = now
+	for t := range f.tickers {
+		if t.next.After(now) {
+			continue
+		}
+
+		select {
+		case t.c <- now:
+		default:
+		}
+
+		// Skip the ticks that were missed, like time.Ticker
+		for !t.next.After(now) {
+			t.next = t.next.Add(t.d)
+		}
+	}
+}
+
+// ticker is a clock.Ticker fired by a Fake clock.
+type ticker struct {
+	fake *Fake
+	c    chan time.Time
+	d    time.Duration
+	next time.Time // Time of the next tick
+}
+
+func (t *ticker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *ticker) Stop() {
+	t.fake.mu.Lock()
+	delete(t.fake.tickers, t)
+	t.fake.mu.Unlock()
+}
@@ -0,0 +1,76 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Unix(1_000_000, 0)
+	f := NewFake(start)
+	if !f.Now().Equal(start) {
+		t.Fatal("Expected the initial time, got:", f.Now())
+	}
+
+	tk := f.NewTicker(time.Second)
+	if f.Tickers() != 1 {
+		t.Fatal("Expected 1 ticker, got:", f.Tickers())
+	}
+
+	f.Advance(999 * time.Millisecond)
+	select {
+	case <-tk.C():
+		t.Fatal("The ticker fired before its interval elapsed")
+	default:
+	}
+
+	f.Advance(time.Millisecond)
+	select {
+	case now := <-tk.C():
+		if !now.Equal(start.Add(time.Second)) {
+			t.Error("Expected the tick to carry the clock time, got:", now)
+		}
+	default:
+		t.Fatal("The ticker did not fire once its interval elapsed")
+	}
+
+	// Missed ticks are dropped, and the next one stays on the interval
+	f.Advance(3500 * time.Millisecond)
+	<-tk.C()
+	select {
+	case <-tk.C():
+		t.Error("Missed ticks should be dropped")
+	default:
+	}
+	f.Advance(499 * time.Millisecond)
+	select {
+	case <-tk.C():
+		t.Error("The ticker fired off its interval")
+	default:
+	}
+	f.Advance(time.Millisecond)
+	select {
+	case <-tk.C():
+	default:
+		t.Error("The ticker did not fire on its interval")
+	}
+
+	// Moving backwards fires nothing
+	f.Set(start)
+	select {
+	case <-tk.C():
+		t.Error("Moving the clock backwards should not fire the ticker")
+	default:
+	}
+
+	tk.Stop()
+	if f.Tickers() != 0 {
+		t.Error("Expected no ticker after Stop, got:", f.Tickers())
+	}
+	f.Advance(time.Hour)
+	select {
+	case <-tk.C():
+		t.Error("A stopped ticker should not fire")
+	default:
+	}
+}
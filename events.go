@@ -0,0 +1,430 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/mem"
+	"github.com/sk-pkg/cache/redis"
+)
+
+// asyncListenerBuffer is the number of events queued for an async listener
+// before new events are dropped.
+const asyncListenerBuffer = 1024
+
+// Event is an activity of a cache driver. It is one of CacheHit, CacheMissed,
+// KeyWritten, KeyForgotten, KeysForgotten, KeyEvicted or Flushed.
+type Event interface {
+	cacheEvent()
+}
+
+// CacheHit is dispatched when a lookup (Get or Pull) found a value.
+type CacheHit struct {
+	Store string // The cache driver, e.g. MemCache
+	Key   string // The key that was looked up
+	Value any    // The value that was found
+}
+
+// CacheMissed is dispatched when a lookup (Get or Pull) found nothing.
+type CacheMissed struct {
+	Store string // The cache driver, e.g. MemCache
+	Key   string // The key that was looked up
+}
+
+// KeyWritten is dispatched when a value was stored by Put, Add, Forever,
+// Increment or Decrement.
+type KeyWritten struct {
+	Store   string // The cache driver, e.g. MemCache
+	Key     string // The key that was written
+	Value   any    // The stored value (the new counter value for Increment and Decrement)
+	Seconds int    // The requested time-to-live in seconds (0 for no expiration, Forever, Increment and Decrement)
+}
+
+// KeyForgotten is dispatched when a key was removed by Forget or Pull.
+type KeyForgotten struct {
+	Store string // The cache driver, e.g. MemCache
+	Key   string // The key that was removed
+}
+
+// KeysForgotten is dispatched when keys were removed in bulk by ForgetPrefix
+// or ForgetPattern. The removed keys are not listed.
+type KeysForgotten struct {
+	Store   string // The cache driver, e.g. MemCache
+	Pattern string // The glob pattern of the removed keys (the escaped prefix followed by "*" for ForgetPrefix)
+	Removed int    // The number of keys removed
+}
+
+// KeyEvicted is dispatched when the memory cache removed an item on its own,
+// because it expired (mem.Expired) or to make room (mem.Evicted).
+type KeyEvicted struct {
+	Store  string          // The cache driver, always MemCache
+	Key    string          // The key that was removed
+	Value  any             // The value that was removed
+	Reason mem.EvictReason // Why the item was removed, mem.Expired or mem.Evicted
+}
+
+// Flushed is dispatched when all items were removed by Flush.
+type Flushed struct {
+	Store string // The cache driver, e.g. MemCache
+}
+
+func (CacheHit) cacheEvent()      {}
+func (CacheMissed) cacheEvent()   {}
+func (KeyWritten) cacheEvent()    {}
+func (KeyForgotten) cacheEvent()  {}
+func (KeysForgotten) cacheEvent() {}
+func (KeyEvicted) cacheEvent()    {}
+func (Flushed) cacheEvent()       {}
+
+// listener is a registered event listener.
+type listener struct {
+	fn    func(Event)
+	queue chan Event // Non-nil for async listeners
+}
+
+// Dispatcher delivers cache events to listeners.
+// Sync listeners run on the goroutine performing the cache operation;
+// async listeners run on their own goroutine, in dispatch order.
+type Dispatcher struct {
+	mu        sync.RWMutex
+	listeners []listener
+	active    atomic.Bool    // Whether any listener is registered
+	dropped   atomic.Uint64  // Events dropped because an async queue was full
+	closed    bool           // Set by Close, guarded by mu
+	async     sync.WaitGroup // Goroutines of the async listeners
+}
+
+// NewDispatcher creates an event dispatcher without listeners.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{}
+}
+
+// Listen registers fn to be called synchronously for every event.
+// fn must not block, as it delays the cache operation that dispatched the event.
+//
+// Example:
+//
+//	c.Events().Listen(func(e cache.Event) {
+//	  if w, ok := e.(cache.KeyWritten); ok && strings.HasPrefix(w.Key, "permission:") {
+//	    audit(w.Key, w.Value)
+//	  }
+//	})
+func (d *Dispatcher) Listen(fn func(Event)) {
+	d.add(listener{fn: fn})
+}
+
+// ListenAsync registers fn to be called for every event on a dedicated goroutine.
+// Events are queued in dispatch order; when the queue is full, new events are
+// dropped and counted in Dropped.
+//
+// Example:
+//
+//	c.Events().ListenAsync(func(e cache.Event) {
+//	  publish(e)
+//	})
+func (d *Dispatcher) ListenAsync(fn func(Event)) {
+	l := listener{fn: fn, queue: make(chan Event, asyncListenerBuffer)}
+	d.async.Add(1)
+	go func() {
+		defer d.async.Done()
+		for e := range l.queue {
+			l.fn(e)
+		}
+	}()
+
+	d.add(l)
+}
+
+// add registers a listener. Listeners added after Close are dropped.
+func (d *Dispatcher) add(l listener) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		if l.queue != nil {
+			close(l.queue)
+		}
+		return
+	}
+	d.listeners = append(d.listeners, l)
+	d.active.Store(true)
+	d.mu.Unlock()
+}
+
+// On registers fn to be called synchronously for every event of type E.
+//
+// Example:
+//
+//	cache.On(c.Events(), func(e cache.KeyEvicted) {
+//	  log.Printf("%s evicted (%s)", e.Key, e.Reason)
+//	})
+func On[E Event](d *Dispatcher, fn func(E)) {
+	d.Listen(func(e Event) {
+		if ev, ok := e.(E); ok {
+			fn(ev)
+		}
+	})
+}
+
+// OnAsync registers fn to be called on a dedicated goroutine for every event of type E.
+func OnAsync[E Event](d *Dispatcher, fn func(E)) {
+	d.ListenAsync(func(e Event) {
+		if ev, ok := e.(E); ok {
+			fn(ev)
+		}
+	})
+}
+
+// Active reports whether any listener is registered.
+// Event producers use it to skip building events nobody listens to.
+func (d *Dispatcher) Active() bool {
+	return d.active.Load()
+}
+
+// Dispatch delivers e to every listener. The synchronous listeners are called
+// without holding the lock, so they may register listeners themselves.
+func (d *Dispatcher) Dispatch(e Event) {
+	if !d.Active() {
+		return
+	}
+
+	// add only appends, so the listeners seen here are never modified. Queues
+	// are fed under the lock, as Close closes them.
+	d.mu.RLock()
+	listeners := d.listeners
+	for _, l := range listeners {
+		if l.queue == nil {
+			continue
+		}
+
+		select {
+		case l.queue <- e:
+		default:
+			d.dropped.Add(1)
+		}
+	}
+	d.mu.RUnlock()
+
+	for _, l := range listeners {
+		if l.queue == nil {
+			l.fn(e)
+		}
+	}
+}
+
+// Close unregisters every listener and stops the goroutines of the async
+// listeners, after they have handled the events already queued. Events
+// dispatched afterwards are discarded. Closing a dispatcher more than once is a no-op.
+//
+// Example:
+//
+//	d := cache.NewDispatcher()
+//	defer d.Close()
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	for _, l := range d.listeners {
+		if l.queue != nil {
+			close(l.queue)
+		}
+	}
+	d.listeners = nil
+	d.active.Store(false)
+	d.mu.Unlock()
+
+	d.async.Wait()
+}
+
+// Dropped returns the number of events dropped because an async listener fell behind.
+func (d *Dispatcher) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// eventCache is a Cache that dispatches an event for every activity of the wrapped cache.
+type eventCache struct {
+	next   Cache
+	store  string
+	events *Dispatcher
+}
+
+// lookup dispatches the result of a Get or Pull.
+func (c *eventCache) lookup(key string, value any, err error) {
+	switch {
+	case err == nil && value != nil:
+		c.events.Dispatch(CacheHit{Store: c.store, Key: key, Value: value})
+	case err == nil || redis.IsMiss(err):
+		c.events.Dispatch(CacheMissed{Store: c.store, Key: key})
+	}
+}
+
+// Put stores data and dispatches KeyWritten.
+func (c *eventCache) Put(key string, value any, seconds int) error {
+	err := c.next.Put(key, value, seconds)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value, Seconds: seconds})
+	}
+	return err
+}
+
+// PutWithJitter stores data with the given TTL jitter and dispatches KeyWritten.
+func (c *eventCache) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	jc, ok := c.next.(jitterCache)
+	if !ok {
+		return c.Put(key, value, seconds)
+	}
+
+	err := jc.PutWithJitter(key, value, seconds, j)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value, Seconds: seconds})
+	}
+	return err
+}
+
+// PutSlidingWithLifetime stores data with a sliding expiration and dispatches
+// KeyWritten with the initial TTL.
+func (c *eventCache) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	err := putSliding(c.next, key, value, idle, lifetime)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value, Seconds: slidingSeconds(idle, lifetime)})
+	}
+	return err
+}
+
+// Add stores data if the key does not exist and dispatches KeyWritten if it did
+// not, as reported by the driver. See tryAdd for wrapped caches that cannot tell.
+func (c *eventCache) Add(key string, value any, seconds int) error {
+	if !c.events.Active() {
+		return c.next.Add(key, value, seconds)
+	}
+	written, err := tryAdd(c.next, key, value, seconds, nil)
+	if written {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value, Seconds: seconds})
+	}
+	return err
+}
+
+// AddWithJitter stores data if the key does not exist with the given TTL jitter,
+// and dispatches KeyWritten if it did not.
+func (c *eventCache) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	if !c.events.Active() {
+		if jc, ok := c.next.(jitterCache); ok {
+			return jc.AddWithJitter(key, value, seconds, j)
+		}
+		return c.next.Add(key, value, seconds)
+	}
+	written, err := tryAdd(c.next, key, value, seconds, &j)
+	if written {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value, Seconds: seconds})
+	}
+	return err
+}
+
+// Get retrieves data and dispatches CacheHit or CacheMissed.
+func (c *eventCache) Get(key string) (any, error) {
+	value, err := c.next.Get(key)
+	c.lookup(key, value, err)
+	return value, err
+}
+
+// Pull retrieves and removes data and dispatches CacheHit and KeyForgotten, or CacheMissed.
+func (c *eventCache) Pull(key string) (any, error) {
+	value, err := c.next.Pull(key)
+	c.lookup(key, value, err)
+	if err == nil && value != nil {
+		c.events.Dispatch(KeyForgotten{Store: c.store, Key: key})
+	}
+	return value, err
+}
+
+// Has checks if an item exists.
+func (c *eventCache) Has(key string) bool {
+	return c.next.Has(key)
+}
+
+// Exists checks if an item exists, reporting errors when the wrapped cache does.
+func (c *eventCache) Exists(key string) (bool, error) {
+	if ec, ok := c.next.(existsCache); ok {
+		return ec.Exists(key)
+	}
+	return c.next.Has(key), nil
+}
+
+// Forever stores data permanently and dispatches KeyWritten.
+func (c *eventCache) Forever(key string, value any) error {
+	err := c.next.Forever(key, value)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value})
+	}
+	return err
+}
+
+// Forget removes an item and dispatches KeyForgotten if it was removed.
+func (c *eventCache) Forget(key string) (bool, error) {
+	removed, err := c.next.Forget(key)
+	if err == nil && removed {
+		c.events.Dispatch(KeyForgotten{Store: c.store, Key: key})
+	}
+	return removed, err
+}
+
+// Increment increases an integer value and dispatches KeyWritten.
+func (c *eventCache) Increment(key string, n int) (int, error) {
+	value, err := c.next.Increment(key, n)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value})
+	}
+	return value, err
+}
+
+// Decrement decreases an integer value and dispatches KeyWritten.
+func (c *eventCache) Decrement(key string, n int) (int, error) {
+	value, err := c.next.Decrement(key, n)
+	if err == nil {
+		c.events.Dispatch(KeyWritten{Store: c.store, Key: key, Value: value})
+	}
+	return value, err
+}
+
+// Flush removes all items and dispatches Flushed.
+func (c *eventCache) Flush() error {
+	err := c.next.Flush()
+	if err == nil {
+		c.events.Dispatch(Flushed{Store: c.store})
+	}
+	return err
+}
+
+// Keys returns the matching keys. Enumerating keys dispatches no event.
+func (c *eventCache) Keys(pattern string) ([]string, error) {
+	return c.next.Keys(pattern)
+}
+
+// Scan runs one step of a key iteration. Enumerating keys dispatches no event.
+func (c *eventCache) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	return c.next.Scan(cursor, pattern, count)
+}
+
+// ForgetPrefix removes the keys starting with prefix and dispatches KeysForgotten
+// if any was removed.
+func (c *eventCache) ForgetPrefix(prefix string) (int, error) {
+	removed, err := forgetPrefix(c.next, prefix)
+	if removed > 0 {
+		c.events.Dispatch(KeysForgotten{Store: c.store, Pattern: glob.Escape(prefix) + "*", Removed: removed})
+	}
+	return removed, err
+}
+
+// ForgetPattern removes the keys matching pattern and dispatches KeysForgotten
+// if any was removed.
+func (c *eventCache) ForgetPattern(pattern string) (int, error) {
+	removed, err := forgetPattern(c.next, pattern)
+	if removed > 0 {
+		c.events.Dispatch(KeysForgotten{Store: c.store, Pattern: pattern, Removed: removed})
+	}
+	return removed, err
+}
@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/mem"
+	"github.com/sk-pkg/cache/stats"
+)
+
+func TestEvents(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Event
+	c.Events().Listen(func(e Event) {
+		got = append(got, e)
+	})
+
+	var written []string
+	On(c.Events(), func(e KeyWritten) {
+		written = append(written, e.Key)
+	})
+
+	_ = c.Put("a", 1, 10)
+	_ = c.Add("a", 2, 10)
+	_ = c.Add("b", 2, 10)
+	_, _ = c.Get("a")
+	_, _ = c.Get("missing")
+	_, _ = c.Pull("b")
+	_, _ = c.Forget("a")
+	_, _ = c.Forget("a")
+	_ = c.Flush()
+
+	want := []Event{
+		KeyWritten{Store: MemCache, Key: "a", Value: 1, Seconds: 10},
+		KeyWritten{Store: MemCache, Key: "b", Value: 2, Seconds: 10},
+		CacheHit{Store: MemCache, Key: "a", Value: 1},
+		CacheMissed{Store: MemCache, Key: "missing"},
+		CacheHit{Store: MemCache, Key: "b", Value: 2},
+		KeyForgotten{Store: MemCache, Key: "b"},
+		KeyForgotten{Store: MemCache, Key: "a"},
+		Flushed{Store: MemCache},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %v", len(want), len(got), got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d: expected %#v, got %#v", i, want[i], got[i])
+		}
+	}
+
+	if len(written) != 2 || written[0] != "a" || written[1] != "b" {
+		t.Error("Typed listener should only receive KeyWritten events:", written)
+	}
+}
+
+func TestEventsAdd(t *testing.T) {
+	for _, mws := range [][]Middleware{nil, {func(next Cache) Cache { return Decorator{Cache: next} }}} {
+		c, err := New(WithMiddleware(mws...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var written []string
+		On(c.Events(), func(e KeyWritten) {
+			written = append(written, e.Key)
+		})
+
+		_ = c.Add("a", 1, 10)
+		_ = c.Add("a", 2, 10)
+		_ = c.AddWithJitter("b", 1, 10, Jitter{Fraction: 0.1})
+		if len(written) != 2 || written[0] != "a" || written[1] != "b" {
+			t.Errorf("middlewares=%d: expected KeyWritten for the stored values only, got %v", len(mws), written)
+		}
+
+		// The driver reports whether it stored the value, without a lookup before
+		if n := c.Mem.Stats().Ops[stats.OpHas]; mws == nil && n != 0 {
+			t.Error("Expected no lookup before Add, got:", n)
+		}
+	}
+}
+
+func TestEventsEvicted(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evicted := make(chan KeyEvicted, 1)
+	OnAsync(c.Events(), func(e KeyEvicted) {
+		evicted <- e
+	})
+
+	_ = c.Put("short", "lived", 1)
+
+	select {
+	case e := <-evicted:
+		if e.Key != "short" || e.Value != "lived" || e.Reason != mem.Expired {
+			t.Errorf("Unexpected eviction event: %+v", e)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("The janitor should dispatch an eviction event for expired items")
+	}
+}
+
+func TestEventsCapacityEvicted(t *testing.T) {
+	c, err := New(WithMemOptions(mem.WithMaxEntries(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var evicted []KeyEvicted
+	On(c.Events(), func(e KeyEvicted) {
+		evicted = append(evicted, e)
+	})
+
+	// Each shard holds a single item, so writing enough keys must evict some
+	for i := 0; i < 100; i++ {
+		_ = c.Put(strconv.Itoa(i), i, 0)
+	}
+
+	if len(evicted) == 0 || evicted[0].Reason != mem.Evicted {
+		t.Fatalf("Expected capacity evictions, got: %+v", evicted)
+	}
+
+	if s := c.Stats()[MemCache]; s.Evictions != uint64(len(evicted)) {
+		t.Error("Expected every eviction to be counted, got:", s.Evictions)
+	}
+}
+
+func TestDispatcherAsyncOrder(t *testing.T) {
+	d := NewDispatcher()
+
+	var mu sync.Mutex
+	var keys []string
+	done := make(chan struct{})
+	d.ListenAsync(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, e.(KeyForgotten).Key)
+		if len(keys) == 3 {
+			close(done)
+		}
+	})
+
+	for _, k := range []string{"a", "b", "c"} {
+		d.Dispatch(KeyForgotten{Key: k})
+	}
+
+	<-done
+	if keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+		t.Error("Async listeners should receive events in dispatch order:", keys)
+	}
+}
+
+func TestDispatcherListenFromListener(t *testing.T) {
+	d := NewDispatcher()
+
+	var late []string
+	d.Listen(func(e Event) {
+		if e.(KeyForgotten).Key == "a" {
+			d.Listen(func(e Event) {
+				late = append(late, e.(KeyForgotten).Key)
+			})
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Dispatch(KeyForgotten{Key: "a"})
+		d.Dispatch(KeyForgotten{Key: "b"})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("A listener registering another listener should not deadlock")
+	}
+
+	if len(late) != 1 || late[0] != "b" {
+		t.Error("Expected the new listener to receive later events only, got:", late)
+	}
+}
+
+func TestEventsForgetPrefix(t *testing.T) {
+	// Bulk removals are reported once, with and without a middleware
+	for _, mws := range [][]Middleware{nil, {func(next Cache) Cache { return Decorator{Cache: next} }}} {
+		c, err := New(WithMiddleware(mws...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []Event
+		On(c.Events(), func(e KeysForgotten) {
+			got = append(got, e)
+		})
+
+		for i := range 3 {
+			_ = c.Put("user:42:"+strconv.Itoa(i), i, 0)
+		}
+		_ = c.Put("user:43:0", 0, 0)
+
+		removed, err := c.ForgetPrefix("user:42:")
+		if err != nil || removed != 3 {
+			t.Errorf("Expected ForgetPrefix to remove 3 keys, got %d, %v", removed, err)
+		}
+		removed, _ = c.ForgetPattern("user:4?:*")
+		if removed != 1 {
+			t.Error("Expected ForgetPattern to remove 1 key, got:", removed)
+		}
+		_, _ = c.ForgetPrefix("user:")
+
+		want := []Event{
+			KeysForgotten{Store: MemCache, Pattern: "user:42:*", Removed: 3},
+			KeysForgotten{Store: MemCache, Pattern: "user:4?:*", Removed: 1},
+		}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		_ = c.Close()
+	}
+}
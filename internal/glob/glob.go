@@ -0,0 +1,149 @@
+// Package glob matches keys against Redis-style glob patterns, so that the mem
+// and redis drivers select the same keys for the same pattern.
+package glob
+
+import "strings"
+
+// special holds the bytes that have a meaning in a pattern.
+const special = `*?[]\`
+
+// Match reports whether s matches pattern, following the rules of the Redis
+// KEYS and SCAN MATCH commands:
+//   - '*' matches any sequence of bytes, including the empty one
+//   - '?' matches a single byte
+//   - '[abc]' matches one of the listed bytes, '[^abc]' any other byte and
+//     '[a-z]' a range of bytes
+//   - '\' matches the next byte literally
+//
+// Matching works on bytes, not runes, like Redis does.
+func Match(pattern, s string) bool {
+	var px, sx int
+	// Where to resume after a mismatch: right after the last '*', consuming one more byte of s
+	starPx, starSx := -1, -1
+
+	for px < len(pattern) || sx < len(s) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starSx = px, sx
+				px++
+				continue
+			case '?':
+				if sx < len(s) {
+					px++
+					sx++
+					continue
+				}
+			case '[':
+				if sx < len(s) {
+					if ok, n := matchClass(pattern[px:], s[sx]); ok {
+						px += n
+						sx++
+						continue
+					}
+				}
+			default:
+				n := 1
+				// A trailing backslash matches itself
+				if c == '\\' && px+1 < len(pattern) {
+					c = pattern[px+1]
+					n = 2
+				}
+				if sx < len(s) && s[sx] == c {
+					px += n
+					sx++
+					continue
+				}
+			}
+		}
+
+		if starPx >= 0 && starSx < len(s) {
+			starSx++
+			px, sx = starPx+1, starSx
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// matchClass matches c against the character class at the start of p, which
+// begins with '['. It returns whether c is in the class and the length of the
+// class in p. An unterminated class extends to the end of p.
+func matchClass(p string, c byte) (bool, int) {
+	i := 1
+	negate := i < len(p) && p[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for ; i < len(p) && p[i] != ']'; i++ {
+		switch {
+		case p[i] == '\\' && i+1 < len(p):
+			i++
+			matched = matched || p[i] == c
+		case i+2 < len(p) && p[i+1] == '-':
+			lo, hi := p[i], p[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			matched = matched || (c >= lo && c <= hi)
+			i += 2
+		default:
+			matched = matched || p[i] == c
+		}
+	}
+	if i < len(p) {
+		i++ // The closing ']'
+	}
+
+	return matched != negate, i
+}
+
+// Prefix returns the literal prefix of pattern: the bytes before its first
+// '*', '?' or '[', with escapes resolved. Every string matching pattern starts
+// with it, so it narrows a search before Match runs.
+func Prefix(pattern string) string {
+	i := strings.IndexAny(pattern, special)
+	if i < 0 {
+		return pattern
+	}
+
+	var b strings.Builder
+	b.WriteString(pattern[:i])
+	for ; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return b.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		b.WriteByte(pattern[i])
+	}
+
+	return b.String()
+}
+
+// Escape returns s with the special bytes of a pattern escaped, so that it
+// only matches itself, e.g. to put a key prefix in front of a pattern.
+func Escape(s string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) * 2)
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(special, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "user:1", true},
+		{"", "", true},
+		{"", "a", false},
+		{"user:*", "user:1", true},
+		{"user:*", "user:", true},
+		{"user:*", "session:1", false},
+		{"*:1", "user:1", true},
+		{"*:1", "user:12", false},
+		{"u*r:*1", "user:21", true},
+		{"u*r:*1", "user:12", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"user:?", "user:", false},
+		{"user:[12]", "user:2", true},
+		{"user:[12]", "user:3", false},
+		{"user:[^12]", "user:3", true},
+		{"user:[^12]", "user:1", false},
+		{"user:[0-9]", "user:5", true},
+		{"user:[9-0]", "user:5", true},
+		{"user:[0-9]", "user:a", false},
+		{"user:[a\\]]", "user:]", true},
+		{"user:[a", "user:a", true},
+		{`user:\*`, "user:*", true},
+		{`user:\*`, "user:1", false},
+		{`user:\`, `user:\`, true},
+		{"**a", "bba", true},
+		{"*a*b", "xaybzb", true},
+		{"*a*b", "xaybz", false},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, s := range []string{"app:", "app*:", `a?b[c]d\e`, ""} {
+		p := Escape(s)
+		if !Match(p, s) {
+			t.Errorf("Escape(%q) = %q does not match itself", s, p)
+		}
+		if Match(p, s+"x") {
+			t.Errorf("Escape(%q) = %q matches a longer string", s, p)
+		}
+		if !Match(p+"*", s+"x") {
+			t.Errorf("Escape(%q)+\"*\" does not match a longer string", s)
+		}
+	}
+
+	if got := Escape("app:"); got != "app:" {
+		t.Errorf(`Escape("app:") = %q, want "app:"`, got)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct{ pattern, want string }{
+		{"user:42:*", "user:42:"},
+		{"user:4?", "user:4"},
+		{"user:[12]", "user:"},
+		{"*", ""},
+		{"user:1", "user:1"},
+		{`app\*:*`, "app*:"},
+		{"a]b*", "a]b"},
+		{`a\`, `a\`},
+	}
+
+	for _, tt := range tests {
+		if got := Prefix(tt.pattern); got != tt.want {
+			t.Errorf("Prefix(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
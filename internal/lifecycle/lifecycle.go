@@ -0,0 +1,8 @@
+// Package lifecycle holds the error shared by the cache drivers once they are
+// closed, so that errors.Is matches it whichever layer returned it.
+package lifecycle
+
+import "errors"
+
+// ErrClosed is returned by the operations of a cache after Close.
+var ErrClosed = errors.New("cache: closed")
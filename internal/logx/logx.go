@@ -0,0 +1,100 @@
+// Package logx holds the structured logging shared by the cache drivers.
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DefaultSlowThreshold is the default duration above which an operation is logged as slow.
+const DefaultSlowThreshold = 100 * time.Millisecond
+
+// Levels holds the level each kind of log record is written at.
+type Levels struct {
+	Error slog.Level // Errors that are not returned to the caller
+	Sweep slog.Level // Janitor sweeps of the memory cache
+	State slog.Level // Driver fallback and circuit breaker transitions
+	Slow  slog.Level // Operations slower than the slow threshold
+}
+
+// DefaultLevels returns the levels used unless configured otherwise:
+// errors, state changes and slow operations are warnings, sweeps are debug records.
+func DefaultLevels() Levels {
+	return Levels{
+		Error: slog.LevelWarn,
+		Sweep: slog.LevelDebug,
+		State: slog.LevelWarn,
+		Slow:  slog.LevelWarn,
+	}
+}
+
+// Logger writes the log records of a cache driver.
+// A nil *Logger discards everything, so drivers can log unconditionally.
+type Logger struct {
+	l      *slog.Logger
+	levels Levels
+	slow   time.Duration
+}
+
+// New returns a Logger writing to l, or nil if l is nil.
+//
+// Parameters:
+//   - l: The destination logger
+//   - levels: The level of every kind of record
+//   - slow: The duration above which operations are logged as slow (0 disables)
+//
+// Returns:
+//   - *Logger: The driver logger
+func New(l *slog.Logger, levels Levels, slow time.Duration) *Logger {
+	if l == nil {
+		return nil
+	}
+	return &Logger{l: l, levels: levels, slow: slow}
+}
+
+// Error logs an error that is not returned to the caller.
+func (l *Logger) Error(msg string, err error, attrs ...slog.Attr) {
+	if l == nil {
+		return
+	}
+	l.log(l.levels.Error, msg, append(attrs, slog.Any("error", err))...)
+}
+
+// Sweep logs a janitor sweep.
+func (l *Logger) Sweep(msg string, attrs ...slog.Attr) {
+	if l == nil {
+		return
+	}
+	l.log(l.levels.Sweep, msg, attrs...)
+}
+
+// State logs a driver fallback or a circuit breaker transition.
+func (l *Logger) State(msg string, attrs ...slog.Attr) {
+	if l == nil {
+		return
+	}
+	l.log(l.levels.State, msg, attrs...)
+}
+
+// Slow logs an operation on key if it took longer than the slow threshold.
+func (l *Logger) Slow(store, op, key string, d time.Duration) {
+	if l == nil || l.slow <= 0 || d < l.slow {
+		return
+	}
+	l.log(l.levels.Slow, "slow cache operation",
+		slog.String("store", store),
+		slog.String("operation", op),
+		slog.String("key", key),
+		slog.Duration("duration", d),
+	)
+}
+
+// log writes a record if its level is enabled.
+func (l *Logger) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, level) {
+		return
+	}
+	l.l.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,43 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(slog.New(slog.NewTextHandler(&buf, nil)), DefaultLevels(), 10*time.Millisecond)
+
+	l.Error("lookup failed", errors.New("boom"), slog.String("key", "k"))
+	l.Sweep("sweep", slog.Int("expired", 1))
+	l.Slow("mem", "get", "fast", time.Millisecond)
+	l.Slow("mem", "get", "slow", time.Second)
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN msg=\"lookup failed\" key=k error=boom") {
+		t.Error("Expected the error record, got:", out)
+	}
+
+	if strings.Contains(out, "sweep") {
+		t.Error("Sweeps are debug records and should be filtered out")
+	}
+
+	if strings.Contains(out, "key=fast") || !strings.Contains(out, "key=slow") {
+		t.Error("Only operations above the threshold should be logged as slow:", out)
+	}
+
+	// A nil logger discards everything
+	var nop *Logger
+	nop.Error("ignored", errors.New("boom"))
+	nop.State("ignored")
+	nop.Slow("mem", "get", "k", time.Hour)
+
+	if New(nil, DefaultLevels(), 0) != nil {
+		t.Error("New should return nil without a destination")
+	}
+}
@@ -0,0 +1,57 @@
+// Package ttl holds the time-to-live helpers shared by the mem and redis drivers.
+package ttl
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter describes how much random extra time is added to a TTL so that keys
+// written together do not all expire in the same instant.
+//
+// Fraction spreads expirations proportionally to the TTL, Max bounds the spread
+// to a fixed duration. When both are set the smaller spread wins; when only Max
+// is set every TTL receives up to Max of extra time. The zero value disables jitter.
+type Jitter struct {
+	Fraction float64        // Extra time as a fraction of the TTL, e.g. 0.1 adds up to 10%
+	Max      time.Duration  // Upper bound of the extra time (0 = unbounded when Fraction is set)
+	Rand     func() float64 // Random source returning values in [0, 1), defaults to math/rand/v2.Float64
+}
+
+// Apply returns d extended by a random amount according to the jitter settings.
+// A non-positive d means "no expiration" and is returned unchanged.
+//
+// Parameters:
+//   - d: The base time-to-live
+//
+// Returns:
+//   - time.Duration: The jittered time-to-live, never shorter than d
+//
+// Example:
+//
+//	j := Jitter{Fraction: 0.1}
+//	ttl := j.Apply(time.Minute) // somewhere in [60s, 66s)
+func (j Jitter) Apply(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+
+	spread := j.Max
+	if j.Fraction > 0 {
+		s := time.Duration(float64(d) * j.Fraction)
+		if spread <= 0 || s < spread {
+			spread = s
+		}
+	}
+
+	if spread <= 0 {
+		return d
+	}
+
+	rnd := j.Rand
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+
+	return d + time.Duration(rnd()*float64(spread))
+}
@@ -0,0 +1,41 @@
+package ttl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterApply(t *testing.T) {
+	one := func() float64 { return 1 }
+	half := func() float64 { return 0.5 }
+
+	tests := []struct {
+		name string
+		j    Jitter
+		d    time.Duration
+		want time.Duration
+	}{
+		{"zero value", Jitter{}, time.Minute, time.Minute},
+		{"no expiration", Jitter{Fraction: 0.5, Rand: one}, 0, 0},
+		{"fraction", Jitter{Fraction: 0.1, Rand: one}, time.Minute, 66 * time.Second},
+		{"max only", Jitter{Max: 10 * time.Second, Rand: half}, time.Minute, 65 * time.Second},
+		{"fraction capped by max", Jitter{Fraction: 0.5, Max: 10 * time.Second, Rand: one}, time.Minute, 70 * time.Second},
+		{"max larger than fraction", Jitter{Fraction: 0.1, Max: time.Hour, Rand: one}, time.Minute, 66 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := tt.j.Apply(tt.d); got != tt.want {
+			t.Errorf("%s: Apply(%v) = %v, want %v", tt.name, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestJitterDefaultRand(t *testing.T) {
+	j := Jitter{Fraction: 0.2}
+	for i := 0; i < 100; i++ {
+		got := j.Apply(time.Minute)
+		if got < time.Minute || got >= 72*time.Second {
+			t.Fatalf("Apply(1m) = %v, want within [1m, 1m12s)", got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package mem
+
+// Lists of the ARC policy a node can be in.
+const (
+	arcT1 uint8 = iota // Stored, accessed once recently
+	arcT2              // Stored, accessed at least twice recently
+	arcB1              // Ghost of a key evicted from T1
+	arcB2              // Ghost of a key evicted from T2
+)
+
+// arcPolicy is an Adaptive Replacement Cache. Stored keys are split between a
+// recency list (T1) and a frequency list (T2). The keys evicted from each list
+// are remembered in ghost lists (B1, B2); storing a key again while its ghost
+// is remembered shifts the target size of T1 towards the list that would have
+// kept it, so the policy adapts to the workload.
+type arcPolicy struct {
+	nodes          map[string]*node // Stored and ghost keys
+	t1, t2, b1, b2 list             // Most recently used first
+	target         int              // Target size of T1
+	capacity       int              // Maximum number of stored keys (0 = the current number)
+	victim         string           // Key last returned by Victim, remembered as a ghost once removed
+}
+
+// NewARC returns an Adaptive Replacement Cache Policy, which balances recency
+// and frequency by learning from recently evicted keys. It remembers up to
+// capacity evicted keys per shard.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(10_000), mem.WithPolicy(mem.NewARC))
+func NewARC(capacity int) Policy {
+	a := &arcPolicy{nodes: make(map[string]*node, 2*capacity), capacity: capacity}
+	a.t1.init()
+	a.t2.init()
+	a.b1.init()
+	a.b2.init()
+	return a
+}
+
+// size returns the number of stored keys the lists are sized after.
+func (a *arcPolicy) size() int {
+	if a.capacity > 0 {
+		return a.capacity
+	}
+	return max(1, a.t1.len+a.t2.len)
+}
+
+// list returns the list n is in.
+func (a *arcPolicy) list(n *node) *list {
+	switch n.region {
+	case arcT2:
+		return &a.t2
+	case arcB1:
+		return &a.b1
+	case arcB2:
+		return &a.b2
+	default:
+		return &a.t1
+	}
+}
+
+// move moves n to the front of the list of region.
+func (a *arcPolicy) move(n *node, region uint8) {
+	a.list(n).remove(n)
+	n.region = region
+	a.list(n).pushFront(n)
+}
+
+// OnInsert adds key to T1, or to T2 if its ghost is remembered, adapting the target size of T1.
+func (a *arcPolicy) OnInsert(key string) {
+	n, ok := a.nodes[key]
+	if !ok {
+		n = &node{key: key, region: arcT1}
+		a.nodes[key] = n
+		a.t1.pushFront(n)
+		a.trim()
+		return
+	}
+
+	switch n.region {
+	case arcB1:
+		// T1 evicted it too early, let T1 grow
+		a.target = min(a.size(), a.target+max(a.b2.len/a.b1.len, 1))
+	case arcB2:
+		// T2 evicted it too early, let T2 grow
+		a.target = max(0, a.target-max(a.b1.len/a.b2.len, 1))
+	}
+	a.move(n, arcT2)
+}
+
+// OnAccess moves key to the front of T2.
+func (a *arcPolicy) OnAccess(key string) {
+	if n, ok := a.nodes[key]; ok && (n.region == arcT1 || n.region == arcT2) {
+		a.move(n, arcT2)
+	}
+}
+
+// OnRemove forgets key, or remembers it as a ghost if it was evicted.
+func (a *arcPolicy) OnRemove(key string) {
+	n, ok := a.nodes[key]
+	if !ok || n.region == arcB1 || n.region == arcB2 {
+		return
+	}
+
+	if key != a.victim {
+		a.list(n).remove(n)
+		delete(a.nodes, key)
+		return
+	}
+
+	a.victim = ""
+	if n.region == arcT1 {
+		a.move(n, arcB1)
+	} else {
+		a.move(n, arcB2)
+	}
+	a.trim()
+}
+
+// Victim returns the least recently used key of T1 if T1 exceeds its target
+// size, and of T2 otherwise.
+func (a *arcPolicy) Victim() (string, bool) {
+	var n *node
+	if a.t1.len > 0 && (a.t1.len > a.target || a.t2.len == 0) {
+		n = a.t1.back()
+	} else {
+		n = a.t2.back()
+	}
+
+	if n == nil {
+		return "", false
+	}
+
+	a.victim = n.key
+	return n.key, true
+}
+
+// trim drops the oldest ghosts so that T1 and B1 together hold at most the
+// cache size, and all lists together twice the cache size.
+func (a *arcPolicy) trim() {
+	c := a.size()
+	for a.t1.len+a.b1.len > c && a.b1.len > 0 {
+		a.drop(a.b1.back())
+	}
+	for a.t1.len+a.t2.len+a.b1.len+a.b2.len > 2*c && a.b2.len > 0 {
+		a.drop(a.b2.back())
+	}
+}
+
+// drop forgets the ghost n.
+func (a *arcPolicy) drop(n *node) {
+	a.list(n).remove(n)
+	delete(a.nodes, n.key)
+}
@@ -0,0 +1,184 @@
+package mem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUncopyable is returned when a value cannot be copied as the copy mode set
+// with WithCopyMode requires, e.g. a channel with SerializeValues.
+var ErrUncopyable = errors.New("mem: cannot copy value")
+
+// CopyMode selects when the cache copies values, so that the caller and the
+// cache do not share the memory of slices, maps and pointers. Modes can be
+// combined with '|'. See WithCopyMode for details.
+type CopyMode int
+
+const (
+	// CopyNone stores and returns the values of the caller as they are (default)
+	CopyNone CopyMode = 0
+	// CopyOnWrite stores a copy of the value given to Put, Add or Forever
+	CopyOnWrite CopyMode = 1
+	// CopyOnRead returns a copy of the stored value from Get and Range
+	CopyOnRead CopyMode = 2
+	// SerializeValues copies on write and on read by encoding and decoding
+	// every value with the Codec, like the redis driver does
+	SerializeValues CopyMode = 4
+)
+
+// Cloner returns a deep copy of a value. See WithCloner for details.
+type Cloner func(value any) (any, error)
+
+// WithCopyMode returns an Option that copies values when they enter or leave
+// the cache (default CopyNone). Without copies, a slice or map changed after
+// Put, or after Get, changes the cached value for every reader.
+//
+//   - CopyOnWrite protects the cache from the writer; readers share the stored copy
+//     and must not modify it
+//   - CopyOnRead protects the cache from the readers; the writer must not modify
+//     the value after storing it
+//   - CopyOnWrite|CopyOnRead isolates both sides
+//   - SerializeValues isolates both sides by encoding and decoding every value with
+//     the Codec set by WithSnapshotCodec. Values behave as in Redis: unexported
+//     fields are lost, and values the Codec cannot encode fail with ErrUncopyable.
+//     It is the slowest mode, as every read and write encodes and decodes
+//
+// Copies are made with the Cloner set by WithCloner, or else with a round trip
+// through the Codec. Values without slices, maps, pointers or interfaces are
+// only copied by SerializeValues. Channels and functions cannot be copied: on
+// their own they are stored shared, inside a value that is copied they fail
+// with ErrUncopyable unless the Cloner handles them. Pull returns the stored
+// value without copying it, as the cache does not keep it.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithCopyMode(mem.CopyOnWrite | mem.CopyOnRead))
+//	tags := []string{"a", "b"}
+//	cache.Put("tags", tags, 0)
+//	tags[0] = "z" // The cached slice still holds "a"
+func WithCopyMode(m CopyMode) Option {
+	return func(o *option) {
+		o.copyMode = m
+	}
+}
+
+// WithCloner returns an Option that sets the function copying values for
+// CopyOnWrite and CopyOnRead, instead of a round trip through the Codec. It is
+// faster and keeps unexported fields, but must return a copy sharing no
+// mutable memory with the value. It is not used by SerializeValues.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithCopyMode(mem.CopyOnRead), mem.WithCloner(func(v any) (any, error) {
+//	    return v.(*User).Clone(), nil
+//	}))
+func WithCloner(fn Cloner) Option {
+	return func(o *option) {
+		o.cloner = fn
+	}
+}
+
+// copyIn returns the value to store for a value given by the caller.
+func (g *cache) copyIn(value any) (any, error) {
+	if g.opt.copyMode&(CopyOnWrite|SerializeValues) == 0 {
+		return value, nil
+	}
+	return g.opt.copy(value)
+}
+
+// copyOut returns the value to hand to the caller for a stored value.
+func (g *cache) copyOut(value any) (any, error) {
+	if g.opt.copyMode&(CopyOnRead|SerializeValues) == 0 {
+		return value, nil
+	}
+	return g.opt.copy(value)
+}
+
+// copy returns a copy of value following the copy mode.
+func (o *option) copy(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		// Nothing to share, and gob cannot encode a nil pointer
+		if v.IsNil() {
+			return value, nil
+		}
+	}
+
+	serialize := o.copyMode&SerializeValues != 0
+	if !serialize && !needsCopy(v.Type()) {
+		return value, nil
+	}
+
+	var copied any
+	var err error
+	if o.cloner != nil && !serialize {
+		copied, err = o.cloner(value)
+	} else {
+		copied, err = roundTrip(o.codec, value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %T: %w", ErrUncopyable, value, err)
+	}
+
+	return copied, nil
+}
+
+// roundTrip encodes value with codec and decodes it into a new value of the
+// same type.
+func roundTrip(codec Codec, value any) (any, error) {
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+
+	p := reflect.New(reflect.TypeOf(value))
+	if err := codec.NewDecoder(&buf).Decode(p.Interface()); err != nil {
+		return nil, err
+	}
+
+	return p.Elem().Interface(), nil
+}
+
+// copyTypes caches the result of needsCopy per reflect.Type, so that values
+// are not walked on every Put and Get.
+var copyTypes sync.Map
+
+// needsCopy reports whether values of type t may share mutable memory with
+// their copies. Strings are immutable, so they are shared safely.
+func needsCopy(t reflect.Type) bool {
+	if need, ok := copyTypes.Load(t); ok {
+		return need.(bool)
+	}
+
+	need := sharesMemory(t)
+	copyTypes.Store(t, need)
+	return need
+}
+
+// sharesMemory walks t for pointers, interfaces, slices and maps. Channels,
+// functions and unsafe pointers cannot be copied, so they are stored shared.
+func sharesMemory(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	case reflect.Array:
+		return sharesMemory(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if sharesMemory(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
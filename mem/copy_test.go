@@ -0,0 +1,216 @@
+package mem
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type profile struct {
+	Name   string
+	Tags   []string
+	secret string
+}
+
+func TestCopyMode(t *testing.T) {
+	tests := []struct {
+		mode                CopyMode
+		isolateWriter       bool
+		isolateReader       bool
+		keepsUnexportedData bool
+	}{
+		{CopyNone, false, false, true},
+		{CopyOnWrite, true, false, false},
+		{CopyOnRead, false, true, false},
+		{CopyOnWrite | CopyOnRead, true, true, false},
+		{SerializeValues, true, true, false},
+	}
+
+	for _, tt := range tests {
+		c := Init(WithCopyMode(tt.mode), WithJanitorInterval(0))
+
+		// The writer changes the slice after storing it
+		tags := []string{"a", "b"}
+		_ = c.Put("tags", tags, 0)
+		tags[0] = "w"
+		v, _ := c.Get("tags")
+		if got := v.([]string)[0] == "a"; got != tt.isolateWriter {
+			t.Errorf("mode %d: expected the writer isolated = %v, got value %v", tt.mode, tt.isolateWriter, v)
+		}
+
+		// A reader changes the slice it got
+		_ = c.Put("tags", []string{"a", "b"}, 0)
+		v, _ = c.Get("tags")
+		v.([]string)[0] = "r"
+		v, _ = c.Get("tags")
+		if got := v.([]string)[0] == "a"; got != tt.isolateReader {
+			t.Errorf("mode %d: expected the readers isolated = %v, got value %v", tt.mode, tt.isolateReader, v)
+		}
+
+		// The codec round trip drops unexported fields
+		_ = c.Add("profile", &profile{Name: "ann", Tags: []string{"x"}, secret: "s"}, 0)
+		v, _ = c.Get("profile")
+		if p := v.(*profile); p.Name != "ann" || (p.secret == "s") != tt.keepsUnexportedData {
+			t.Errorf("mode %d: unexpected profile %+v", tt.mode, p)
+		}
+
+		_ = c.Close()
+	}
+}
+
+func TestCopyModeValues(t *testing.T) {
+	c := Init(WithCopyMode(SerializeValues), WithJanitorInterval(0))
+	defer c.Close()
+
+	// Values the codec cannot encode are rejected and leave the cache unchanged
+	_ = c.Put("ch", "old", 0)
+	if err := c.Put("ch", make(chan int), 0); !errors.Is(err, ErrUncopyable) {
+		t.Error("Expected Put to return ErrUncopyable, got:", err)
+	}
+	if err := c.Add("other", []chan int{make(chan int)}, 0); !errors.Is(err, ErrUncopyable) {
+		t.Error("Expected Add to return ErrUncopyable, got:", err)
+	}
+	if v, _ := c.Get("ch"); v != "old" {
+		t.Error("Expected the old value to be kept, got:", v)
+	}
+	if c.Has("other") {
+		t.Error("Expected the uncopyable value not to be stored")
+	}
+	if s := c.Stats(); s.Errors != 2 {
+		t.Error("Expected 2 errors in the statistics, got:", s.Errors)
+	}
+
+	// nil values and nil slices, maps and pointers are stored as they are
+	var p *profile
+	for key, value := range map[string]any{"nil": nil, "nil slice": []int(nil), "nil pointer": p} {
+		if err := c.Put(key, value, 0); err != nil {
+			t.Errorf("Put(%q) failed: %v", key, err)
+		}
+		if v, err := c.Get(key); err != nil || !reflect.DeepEqual(v, value) {
+			t.Errorf("Get(%q) = %v, %v, want %v", key, v, err, value)
+		}
+	}
+
+	// Range hands out copies
+	_ = c.Put("map", map[string]int{"n": 1}, 0)
+	c.Range(func(key string, value any) bool {
+		if m, ok := value.(map[string]int); ok {
+			m["n"] = 2
+		}
+		return true
+	})
+	if v, _ := c.Get("map"); v.(map[string]int)["n"] != 1 {
+		t.Error("Expected Range not to expose the stored map, got:", v)
+	}
+}
+
+func TestCloner(t *testing.T) {
+	calls := 0
+	fail := errors.New("boom")
+	c := Init(WithCopyMode(CopyOnWrite|CopyOnRead), WithJanitorInterval(0), WithCloner(func(v any) (any, error) {
+		calls++
+		p, ok := v.(*profile)
+		if !ok {
+			return nil, fail
+		}
+		clone := *p
+		clone.Tags = slices.Clone(p.Tags)
+		return &clone, nil
+	}))
+	defer c.Close()
+
+	in := &profile{Name: "ann", Tags: []string{"x"}, secret: "s"}
+	_ = c.Put("profile", in, 0)
+	in.Tags[0] = "changed"
+	v, _ := c.Get("profile")
+	if p := v.(*profile); p == in || p.Tags[0] != "x" || p.secret != "s" {
+		t.Errorf("Expected a clone keeping unexported fields, got %+v", p)
+	}
+	if calls != 2 {
+		t.Error("Expected the cloner to be called on write and on read, calls:", calls)
+	}
+
+	// Values without references are not copied
+	_ = c.Put("n", 42, 0)
+	if v, _ := c.Get("n"); v != 42 || calls != 2 {
+		t.Errorf("Expected 42 without cloning, got %v after %d calls", v, calls)
+	}
+
+	if err := c.Put("tags", []string{"a"}, 0); !errors.Is(err, ErrUncopyable) || !errors.Is(err, fail) {
+		t.Error("Expected the cloner error wrapped in ErrUncopyable, got:", err)
+	}
+
+	// Channels and functions cannot be copied and are stored shared
+	ch := make(chan int)
+	if err := c.Put("ch", ch, 0); err != nil {
+		t.Fatal("Expected a channel to be stored, got:", err)
+	}
+	if v, _ := c.Get("ch"); v != ch || calls != 3 {
+		t.Errorf("Expected the same channel without cloning, got %v after %d calls", v, calls)
+	}
+
+	// A closed cache does not copy the value before failing
+	_ = c.Close()
+	if err := c.Put("profile", in, 0); !errors.Is(err, ErrClosed) || calls != 3 {
+		t.Errorf("Expected ErrClosed without cloning, got %v after %d calls", err, calls)
+	}
+	if err := c.PutSliding("profile", in, time.Minute); !errors.Is(err, ErrClosed) || calls != 3 {
+		t.Errorf("Expected ErrClosed without cloning, got %v after %d calls", err, calls)
+	}
+}
+
+func BenchmarkCopyMode(b *testing.B) {
+	value := &profile{Name: "ann", Tags: []string{"a", "b", "c"}}
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"none", nil},
+		{"cloner", []Option{WithCopyMode(CopyOnWrite | CopyOnRead), WithCloner(func(v any) (any, error) {
+			clone := *v.(*profile)
+			clone.Tags = slices.Clone(clone.Tags)
+			return &clone, nil
+		})}},
+		{"serialize", []Option{WithCopyMode(SerializeValues)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c := Init(bm.opts...)
+			defer c.Close()
+
+			for i := 0; i < b.N; i++ {
+				_ = c.Put("profile", value, 0)
+				_, _ = c.Get("profile")
+			}
+		})
+	}
+}
+
+func BenchmarkCopyModeValue(b *testing.B) {
+	// Values without references are never copied, only their type is looked up
+	type point struct {
+		X, Y int
+		Name string
+	}
+	value := point{X: 1, Y: 2, Name: "origin"}
+
+	for _, bm := range []struct {
+		name string
+		opts []Option
+	}{
+		{"none", nil},
+		{"copy", []Option{WithCopyMode(CopyOnWrite | CopyOnRead)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c := Init(bm.opts...)
+			defer c.Close()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = c.Put("point", value, 0)
+				_, _ = c.Get("point")
+			}
+		})
+	}
+}
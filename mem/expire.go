@@ -0,0 +1,51 @@
+package mem
+
+// expired reports whether it has an expiration time that has passed.
+// The clock is only read for items that expire.
+func (g *cache) expired(it *item) bool {
+	return it.Expiration > 0 && g.opt.clock.Now().UnixNano() > it.Expiration
+}
+
+// lookup returns the live item stored under key. An expired item counts as
+// absent: it is removed from the shard and returned as gone, to be reported
+// with expire once the lock is released. The caller must hold the write lock.
+func (g *cache) lookup(key string) (it, gone *item) {
+	it, ok := g.items[key]
+	if !ok {
+		return nil, nil
+	}
+
+	if g.expired(it) {
+		g.remove(it)
+		return nil, it
+	}
+
+	return it, nil
+}
+
+// reap removes an expired item found under the read lock, unless it was
+// written again in the meantime, and reports it.
+// It must be called without holding the lock.
+func (g *cache) reap(it *item) {
+	g.Lock()
+	ok := g.items[it.key] == it && g.expired(it)
+	if ok {
+		g.remove(it)
+	}
+	g.Unlock()
+
+	if ok {
+		g.expire(it)
+	}
+}
+
+// expire counts an item removed because it expired and reports it to the
+// eviction callback. It accepts nil, and must be called without holding the lock.
+func (g *cache) expire(gone *item) {
+	if gone == nil {
+		return
+	}
+
+	g.stats.Expire(1)
+	g.notify(gone.key, gone.value, Expired)
+}
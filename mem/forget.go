@@ -0,0 +1,98 @@
+package mem
+
+import (
+	"time"
+
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/stats"
+)
+
+// ForgetPrefix removes every key starting with prefix, e.g. all the keys of a
+// user. The shards are locked one at a time, so a key written meanwhile may
+// survive. Without WithPrefixIndex every item of every shard is checked.
+// Removed items are reported to the eviction callback as Deleted, or as
+// Expired if their TTL had elapsed.
+//
+// Parameters:
+//   - prefix: The prefix of the keys to remove, empty to remove every key
+//
+// Returns:
+//   - int: The number of items removed, not counting expired ones
+//   - error: ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	removed, err := cache.ForgetPrefix("user:42:")
+func (c Cache) ForgetPrefix(prefix string) (int, error) {
+	return c.forgetMatching(prefix, "")
+}
+
+// ForgetPattern removes every key matching a Redis-style glob pattern ('*',
+// '?', '[...]' and '\' escapes); an empty pattern matches every key. With
+// WithPrefixIndex, only the keys starting with the literal start of the
+// pattern are checked. See ForgetPrefix for the guarantees.
+//
+// Parameters:
+//   - pattern: The glob pattern of the keys to remove
+//
+// Returns:
+//   - int: The number of items removed, not counting expired ones
+//   - error: ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	removed, err := cache.ForgetPattern("user:*:permissions")
+func (c Cache) ForgetPattern(pattern string) (int, error) {
+	return c.forgetMatching(glob.Prefix(pattern), pattern)
+}
+
+// forgetMatching removes the keys starting with prefix and, unless it is
+// empty, matching pattern from every shard.
+func (c Cache) forgetMatching(prefix, pattern string) (int, error) {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	start := time.Now()
+
+	removed := 0
+	for _, group := range c {
+		removed += group.forgetMatching(prefix, pattern)
+	}
+
+	if len(c) > 0 {
+		c[0].stats.Record(stats.OpForgetMatch, time.Since(start), stats.None)
+	}
+
+	return removed, nil
+}
+
+// forgetMatching removes the matching keys of the shard and reports them once
+// the lock is released. It returns the number of live items removed.
+func (g *cache) forgetMatching(prefix, pattern string) int {
+	var matched []*item
+
+	g.Lock()
+	g.each(prefix, func(key string, it *item) {
+		if pattern == "" || glob.Match(pattern, key) {
+			matched = append(matched, it)
+		}
+	})
+	for _, it := range matched {
+		g.remove(it)
+	}
+	g.Unlock()
+
+	removed := 0
+	for _, it := range matched {
+		if g.expired(it) {
+			g.expire(it)
+			continue
+		}
+		g.notify(it.key, it.value, Deleted)
+		removed++
+	}
+	g.stats.Delete(removed)
+
+	return removed
+}
@@ -0,0 +1,140 @@
+package mem
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+)
+
+func TestForgetPrefix(t *testing.T) {
+	for _, indexed := range []bool{false, true} {
+		t.Run("indexed="+strconv.FormatBool(indexed), func(t *testing.T) {
+			clk := clocktest.NewFake(time.Unix(1000, 0))
+			var mu sync.Mutex
+			reasons := map[string]EvictReason{}
+			opts := []Option{
+				WithClock(clk),
+				WithJanitorInterval(0),
+				WithOnEvicted(func(key string, _ any, reason EvictReason) {
+					mu.Lock()
+					reasons[key] = reason
+					mu.Unlock()
+				}),
+			}
+			if indexed {
+				opts = append(opts, WithPrefixIndex())
+			}
+			c := Init(opts...)
+			defer c.Close()
+
+			for i := range 10 {
+				_ = c.Put("user:42:"+strconv.Itoa(i), i, 0)
+				_ = c.Put("user:43:"+strconv.Itoa(i), i, 0)
+			}
+			_ = c.Put("user:42", "profile", 0)
+			_ = c.Put("user:42:session", "token", 1)
+			clk.Advance(2 * time.Second)
+
+			removed, err := c.ForgetPrefix("user:42:")
+			if err != nil || removed != 10 {
+				t.Errorf("Expected ForgetPrefix to remove 10 live keys, got %d, %v", removed, err)
+			}
+			if reasons["user:42:3"] != Deleted || reasons["user:42:session"] != Expired {
+				t.Error("Expected live keys reported as Deleted and expired ones as Expired, got:", reasons)
+			}
+			if keys, _ := c.Keys("user:42*"); !slices.Equal(keys, []string{"user:42"}) {
+				t.Error("Expected only user:42 to remain, got:", keys)
+			}
+			if s := c.Stats(); s.Deletes != 10 || s.Expirations != 1 {
+				t.Errorf("Expected 10 deletes and 1 expiration, got %d and %d", s.Deletes, s.Expirations)
+			}
+
+			removed, _ = c.ForgetPattern("user:4[0-3]:[5-9]")
+			if removed != 5 {
+				t.Error("Expected ForgetPattern to remove 5 keys, got:", removed)
+			}
+			keys, _ := c.Keys("user:43:*")
+			slices.Sort(keys)
+			if !slices.Equal(keys, []string{"user:43:0", "user:43:1", "user:43:2", "user:43:3", "user:43:4"}) {
+				t.Error("Expected the keys not matching the pattern to remain, got:", keys)
+			}
+
+			// Removed keys can be written again
+			_ = c.Put("user:42:1", 1, 0)
+			if keys, _ := c.Keys("user:42:*"); len(keys) != 1 {
+				t.Error("Expected a key written after ForgetPrefix to be found, got:", keys)
+			}
+
+			// An empty prefix removes everything, like Flush
+			if removed, _ := c.ForgetPrefix(""); removed != 7 {
+				t.Error("Expected an empty prefix to remove every key, got:", removed)
+			}
+
+			_ = c.Close()
+			if _, err := c.ForgetPrefix("user:"); !errors.Is(err, ErrClosed) {
+				t.Error("Expected ForgetPrefix to return ErrClosed, got:", err)
+			}
+			if _, err := c.ForgetPattern("user:*"); !errors.Is(err, ErrClosed) {
+				t.Error("Expected ForgetPattern to return ErrClosed, got:", err)
+			}
+		})
+	}
+}
+
+func TestPrefixIndex(t *testing.T) {
+	c := Init(WithPrefixIndex(), WithShards(1), WithMaxEntries(3))
+	defer c.Close()
+
+	// The index follows evictions, overwrites, deletes and flushes
+	for i := range 5 {
+		_ = c.Put("k:"+strconv.Itoa(i), i, 0)
+	}
+	_ = c.Put("k:4", 40, 0)
+	_, _ = c.Forget("k:3")
+	keys, _ := c.Keys("k:*")
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"k:2", "k:4"}) {
+		t.Error("Expected the index to match the stored keys, got:", keys)
+	}
+
+	var indexed []string
+	c[0].index.walkPrefix("", func(key string) {
+		indexed = append(indexed, key)
+	})
+	if !slices.Equal(indexed, []string{"k:2", "k:4"}) {
+		t.Error("Expected evicted and forgotten keys to leave the index, got:", indexed)
+	}
+
+	_ = c.Flush()
+	if len(c[0].index.root.children) != 0 {
+		t.Error("Expected Flush to empty the index")
+	}
+}
+
+func BenchmarkForgetPrefix(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		b.Run("indexed="+strconv.FormatBool(indexed), func(b *testing.B) {
+			var opts []Option
+			if indexed {
+				opts = append(opts, WithPrefixIndex())
+			}
+			c := Init(opts...)
+			defer c.Close()
+			for i := range 100000 {
+				_ = c.Put("user:"+strconv.Itoa(i)+":profile", i, 0)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := "user:" + strconv.Itoa(i%100000) + ":"
+				_ = c.Put(key+"profile", i, 0)
+				_, _ = c.ForgetPrefix(key)
+			}
+		})
+	}
+}
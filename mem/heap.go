@@ -0,0 +1,92 @@
+package mem
+
+import "container/heap"
+
+// sweepBatch is the maximum number of expired items a janitor removes while
+// holding the write lock. A sweep with more items due releases the lock between
+// batches, so that reads and writes are never blocked for long.
+const sweepBatch = 1024
+
+// expiryHeap is a min-heap of the items of a shard that expire, ordered by
+// expiration time, so that a sweep only visits the items that are due.
+type expiryHeap []*item
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].Expiration < h[j].Expiration }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old) - 1
+	it := old[n]
+	old[n] = nil // Let the item be garbage collected
+	it.index = -1
+	*h = old[:n]
+	return it
+}
+
+// schedule keeps the position of it in the expiry heap in line with its
+// expiration time. The caller must hold the write lock.
+func (g *cache) schedule(it *item) {
+	switch {
+	case it.Expiration > 0 && it.index < 0:
+		heap.Push(&g.expiries, it)
+	case it.Expiration > 0:
+		heap.Fix(&g.expiries, it.index)
+	case it.index >= 0:
+		heap.Remove(&g.expiries, it.index)
+	}
+}
+
+// unschedule removes it from the expiry heap. The caller must hold the write lock.
+func (g *cache) unschedule(it *item) {
+	if it.index >= 0 {
+		heap.Remove(&g.expiries, it.index)
+	}
+}
+
+// due removes up to n items that expired before now from the shard, earliest
+// first, and returns them. The caller must hold the write lock.
+func (g *cache) due(now int64, n int) []*item {
+	var expired []*item
+	for len(g.expiries) > 0 && len(expired) < n {
+		it := g.expiries[0]
+		if now <= it.Expiration {
+			break
+		}
+		g.remove(it)
+		expired = append(expired, it)
+	}
+
+	return expired
+}
+
+// countDue returns the number of items that expired before now. Thanks to the
+// heap order, it only visits those items and their children.
+// The caller must hold the lock.
+func (h expiryHeap) countDue(now int64) int {
+	n := 0
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if i >= len(h) || now <= h[i].Expiration {
+			continue
+		}
+		n++
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+
+	return n
+}
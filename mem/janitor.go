@@ -1,48 +1,85 @@
 package mem
 
-import "time"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/sk-pkg/cache/clock"
+)
 
 // janitor is responsible for periodically cleaning up expired cache items.
 // It runs as a separate goroutine and can be stopped when no longer needed.
 type janitor struct {
 	Interval time.Duration // How frequently the janitor checks for expired items
+	ticker   clock.Ticker  // Ticker of the interval, from the clock of the cache
 	stop     chan struct{} // Channel used to signal the janitor to stop
+	done     chan struct{} // Closed once the janitor has stopped
 }
 
 // run starts the janitor's cleanup process for a given cache.
-// It periodically scans the cache and removes expired items.
+// It periodically removes the expired items of the cache.
 //
 // Parameters:
 //   - c: The cache shard to clean up
 //
 // The function runs indefinitely until signaled to stop via the stop channel.
 func (j *janitor) run(c *cache) {
-	// Create a ticker that triggers at the specified interval
-	ticker := time.NewTicker(j.Interval)
+	defer close(j.done)
+
 	for {
 		select {
-		case <-ticker.C:
-			{
-				// Get current time for expiration comparison
-				now := time.Now().UnixNano()
-				c.Lock()
-				// Iterate through all items in the cache
-				for k, v := range c.items {
-					// If the item has an expiration time and it's in the past, delete it
-					if v.Expiration > 0 && now > v.Expiration {
-						delete(c.items, k)
-					}
-				}
-				c.Unlock()
-			}
+		case <-j.ticker.C():
+			c.sweep()
 		case <-j.stop:
 			// Stop the ticker and exit the function when signaled
-			ticker.Stop()
+			j.ticker.Stop()
 			return
 		}
 	}
 }
 
+// sweep removes the items of the shard that have expired. Items are taken
+// from the expiry heap, so only the items that are due are visited, and the
+// lock is released every sweepBatch items.
+//
+// Returns:
+//   - int: The number of items removed
+func (c *cache) sweep() int {
+	// Get current time for expiration comparison
+	start := time.Now()
+	now := c.opt.clock.Now().UnixNano()
+	expired := 0
+	remaining := 0
+	for {
+		c.Lock()
+		batch := c.due(now, sweepBatch)
+		remaining = len(c.items)
+		c.Unlock()
+
+		// Report the batch once the lock is released
+		expired += len(batch)
+		c.stats.Expire(len(batch))
+		for _, it := range batch {
+			c.notify(it.key, it.value, Expired)
+		}
+
+		if len(batch) < sweepBatch {
+			break
+		}
+	}
+
+	if expired > 0 {
+		c.opt.log.Sweep("cache janitor sweep",
+			slog.String("store", "mem"),
+			slog.Int("expired", expired),
+			slog.Int("remaining", remaining),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+
+	return expired
+}
+
 // runJanitor creates and starts a new janitor for a cache shard.
 // It initializes the janitor with the specified cleanup interval and
 // starts its cleanup process in a separate goroutine.
@@ -53,13 +90,16 @@ func (j *janitor) run(c *cache) {
 //
 // Example:
 //
-//	cache := &cache{items: make(map[string]item)}
+//	cache := &cache{items: make(map[string]*item), opt: opt}
 //	runJanitor(cache, time.Minute) // Run cleanup every minute
 func runJanitor(c *cache, ci time.Duration) {
-	// Create a new janitor with the specified interval
+	// Create a new janitor with the specified interval, and its ticker right
+	// away so that it exists once Init returns
 	j := &janitor{
 		Interval: ci,
+		ticker:   c.opt.clock.NewTicker(ci),
 		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 
 	// Attach the janitor to the cache
@@ -69,16 +109,14 @@ func runJanitor(c *cache, ci time.Duration) {
 	go j.run(c)
 }
 
-// stopJanitor signals the janitor to stop its cleanup process.
-// This function is typically called when the cache is being destroyed
-// or when cleanup is no longer needed.
+// stopJanitor signals the janitor to stop its cleanup process and waits
+// until it has stopped. It is called by Cache.Close, as the running janitor
+// keeps the shard reachable and would never let it be garbage collected.
 //
 // Parameters:
 //   - c: The cache shard whose janitor should be stopped
-//
-// This function is designed to be used with runtime.SetFinalizer to ensure
-// the janitor is stopped when the cache is garbage collected.
 func stopJanitor(c *cache) {
-	// Send a signal to the janitor to stop
-	c.janitor.stop <- struct{}{}
+	// Signal the janitor to stop, then wait for a sweep in progress to finish
+	close(c.janitor.stop)
+	<-c.janitor.done
 }
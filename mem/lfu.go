@@ -0,0 +1,95 @@
+package mem
+
+// lfuPolicy evicts the least frequently used item in constant time: items are
+// grouped in one list per access count, and among the items of the lowest
+// count the least recently used one is evicted.
+type lfuPolicy struct {
+	nodes   map[string]*node
+	buckets map[int]*list // Items by access count, most recently used first
+	minFreq int           // Lowest access count with items, 0 if unknown
+}
+
+// NewLFU returns a Policy evicting the least frequently used item, and the
+// least recently used one among items used equally often.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(10_000), mem.WithPolicy(mem.NewLFU))
+func NewLFU(capacity int) Policy {
+	return &lfuPolicy{nodes: make(map[string]*node, capacity), buckets: make(map[int]*list)}
+}
+
+// bucket returns the list of items accessed freq times, creating it if needed.
+func (p *lfuPolicy) bucket(freq int) *list {
+	b, ok := p.buckets[freq]
+	if !ok {
+		b = &list{}
+		b.init()
+		p.buckets[freq] = b
+	}
+	return b
+}
+
+// unlink removes n from its bucket, dropping the bucket once it is empty.
+func (p *lfuPolicy) unlink(n *node) {
+	b := p.buckets[n.freq]
+	b.remove(n)
+	if b.len == 0 {
+		delete(p.buckets, n.freq)
+		if p.minFreq == n.freq {
+			p.minFreq = 0
+		}
+	}
+}
+
+// OnInsert adds key with an access count of 1.
+func (p *lfuPolicy) OnInsert(key string) {
+	n := &node{key: key, freq: 1}
+	p.nodes[key] = n
+	p.bucket(1).pushFront(n)
+	p.minFreq = 1
+}
+
+// OnAccess increments the access count of key.
+func (p *lfuPolicy) OnAccess(key string) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	// When n was the last item with the lowest count, its new count is the lowest
+	last := p.minFreq == n.freq && p.buckets[n.freq].len == 1
+
+	p.unlink(n)
+	n.freq++
+	p.bucket(n.freq).pushFront(n)
+	if last {
+		p.minFreq = n.freq
+	}
+}
+
+// OnRemove forgets key.
+func (p *lfuPolicy) OnRemove(key string) {
+	if n, ok := p.nodes[key]; ok {
+		p.unlink(n)
+		delete(p.nodes, key)
+	}
+}
+
+// Victim returns the least recently used key among the least frequently used ones.
+func (p *lfuPolicy) Victim() (string, bool) {
+	if len(p.nodes) == 0 {
+		return "", false
+	}
+
+	// The lowest count is lost when its last item leaves, find it again
+	if p.minFreq == 0 {
+		for freq := range p.buckets {
+			if p.minFreq == 0 || freq < p.minFreq {
+				p.minFreq = freq
+			}
+		}
+	}
+
+	return p.buckets[p.minFreq].back().key, true
+}
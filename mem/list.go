@@ -0,0 +1,109 @@
+package mem
+
+// node is the entry of a key in the lists of an eviction policy.
+type node struct {
+	key        string
+	prev, next *node
+	freq       int   // Access count, used by LFU
+	region     uint8 // List the node is in, used by policies with several lists
+}
+
+// list is a doubly linked list of nodes.
+type list struct {
+	root node // Sentinel: root.next is the front node, root.prev the back node
+	len  int  // Number of nodes in the list
+}
+
+// init empties the list.
+func (l *list) init() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+}
+
+// pushFront inserts n at the front of the list.
+func (l *list) pushFront(n *node) {
+	n.prev = &l.root
+	n.next = l.root.next
+	n.prev.next = n
+	n.next.prev = n
+	l.len++
+}
+
+// remove unlinks n from the list.
+func (l *list) remove(n *node) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	n.prev = nil
+	n.next = nil
+	l.len--
+}
+
+// moveToFront moves n to the front of the list.
+func (l *list) moveToFront(n *node) {
+	if l.root.next == n {
+		return
+	}
+	l.remove(n)
+	l.pushFront(n)
+}
+
+// back returns the node at the back of the list, or nil if the list is empty.
+func (l *list) back() *node {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// itemList is a doubly linked list threaded through the prev and next fields
+// of items, so that a policy can order the items of a shard without a node per key.
+type itemList struct {
+	root item // Sentinel: root.next is the front item, root.prev the back item
+	len  int  // Number of items in the list
+}
+
+// init empties the list.
+func (l *itemList) init() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+	l.len = 0
+}
+
+// pushFront inserts it at the front of the list.
+func (l *itemList) pushFront(it *item) {
+	it.prev = &l.root
+	it.next = l.root.next
+	it.prev.next = it
+	it.next.prev = it
+	l.len++
+}
+
+// remove unlinks it from the list. Items that are not linked are ignored.
+func (l *itemList) remove(it *item) {
+	if it.prev == nil {
+		return
+	}
+	it.prev.next = it.next
+	it.next.prev = it.prev
+	it.prev = nil
+	it.next = nil
+	l.len--
+}
+
+// moveToFront moves it to the front of the list.
+func (l *itemList) moveToFront(it *item) {
+	if l.root.next == it || it.prev == nil {
+		return
+	}
+	l.remove(it)
+	l.pushFront(it)
+}
+
+// back returns the item at the back of the list, or nil if the list is empty.
+func (l *itemList) back() *item {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
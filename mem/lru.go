@@ -0,0 +1,92 @@
+package mem
+
+// lruPolicy evicts the least recently used item, or with fifo set the oldest one.
+// Inside a shard the order is linked through the items themselves, so the
+// policy needs no map of its own; keys reported through the Policy methods are
+// tracked in keys.
+type lruPolicy struct {
+	order itemList         // Most recently used (or inserted) first
+	keys  map[string]*item // Items of the keys passed to the Policy methods, nil until one is
+	fifo  bool             // Whether accesses leave the order unchanged
+}
+
+// NewLRU returns a Policy evicting the least recently used item.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(10_000), mem.WithPolicy(mem.NewLRU))
+func NewLRU(capacity int) Policy {
+	return newLRU(capacity, false)
+}
+
+// NewFIFO returns a Policy evicting the oldest item, regardless of how it is used.
+// Reads do not reorder items, which makes it the cheapest policy.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(10_000), mem.WithPolicy(mem.NewFIFO))
+func NewFIFO(capacity int) Policy {
+	return newLRU(capacity, true)
+}
+
+// newLRU returns an empty LRU or FIFO policy.
+func newLRU(_ int, fifo bool) *lruPolicy {
+	p := &lruPolicy{fifo: fifo}
+	p.order.init()
+	return p
+}
+
+// insert adds it as the most recent item.
+func (p *lruPolicy) insert(it *item) {
+	p.order.pushFront(it)
+}
+
+// access marks it as the most recently used item, unless the policy is FIFO.
+func (p *lruPolicy) access(it *item) {
+	if !p.fifo {
+		p.order.moveToFront(it)
+	}
+}
+
+// remove unlinks it.
+func (p *lruPolicy) remove(it *item) {
+	p.order.remove(it)
+}
+
+// victim returns the least recently used (or oldest) item.
+func (p *lruPolicy) victim() *item {
+	return p.order.back()
+}
+
+// OnInsert adds key as the most recent item.
+func (p *lruPolicy) OnInsert(key string) {
+	if p.keys == nil {
+		p.keys = make(map[string]*item)
+	}
+	it := &item{key: key}
+	p.keys[key] = it
+	p.insert(it)
+}
+
+// OnAccess marks key as the most recently used item, unless the policy is FIFO.
+func (p *lruPolicy) OnAccess(key string) {
+	if it, ok := p.keys[key]; ok {
+		p.access(it)
+	}
+}
+
+// OnRemove forgets key.
+func (p *lruPolicy) OnRemove(key string) {
+	if it, ok := p.keys[key]; ok {
+		p.remove(it)
+		delete(p.keys, key)
+	}
+}
+
+// Victim returns the least recently used (or oldest) key.
+func (p *lruPolicy) Victim() (string, bool) {
+	if it := p.victim(); it != nil {
+		return it.key, true
+	}
+	return "", false
+}
@@ -6,14 +6,42 @@ package mem
 import (
 	"errors"
 	"fmt"
-	"runtime"
+	"hash/maphash"
+	"log/slog"
+	"math/bits"
+	"math/rand/v2"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/sk-pkg/cache/clock"
+	"github.com/sk-pkg/cache/internal/lifecycle"
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/internal/ttl"
+	"github.com/sk-pkg/cache/stats"
 )
 
-// cacheGroupCount defines the number of shards for the cache.
+// DefaultShards is the default number of shards of the cache.
 // Sharding helps reduce lock contention in concurrent environments.
-const cacheGroupCount = 32
+const DefaultShards = 32
+
+// DefaultShardCapacity is the default initial capacity of the map of each shard.
+const DefaultShardCapacity = 256
+
+// DefaultJanitorInterval is the default interval at which each shard removes its expired items.
+const DefaultJanitorInterval = time.Second
+
+// DefaultLatencySampleRate is the default rate at which operation latencies are sampled.
+// Reading the clock costs about as much as a cache hit, so only one operation in
+// DefaultLatencySampleRate is timed. Counters are always exact.
+const DefaultLatencySampleRate = 16
+
+// DefaultSlowThreshold is the default duration above which an operation is logged as slow.
+const DefaultSlowThreshold = logx.DefaultSlowThreshold
+
+// ErrClosed is returned by the operations of a cache after Close.
+var ErrClosed = lifecycle.ErrClosed
 
 // Cache is a collection of cache shards that together form the complete cache.
 // Operations on the cache are distributed across shards based on key hashing.
@@ -22,20 +50,403 @@ type Cache []*cache
 // cache represents a single shard of the cache system.
 // Each shard has its own lock to reduce contention.
 type cache struct {
-	items        map[string]item // Map of cached items
-	janitor      *janitor        // Reference to the cleanup process
-	sync.RWMutex                 // Lock for concurrent access
+	items        map[string]*item // Map of cached items
+	policy       Policy           // Chooses the items to evict, nil unless the shard is bounded
+	linked       itemPolicy       // The policy when it links the items itself, nil otherwise
+	capacity     int              // Maximum number of items (0 = unbounded)
+	expiries     expiryHeap       // Items that expire, earliest first
+	index        *radix           // Keys of the shard by prefix, nil unless WithPrefixIndex is given
+	bytes        int64            // Estimated bytes stored in the shard
+	budget       *budget          // Byte limit shared by every shard, nil unless set
+	janitor      *janitor         // Reference to the cleanup process
+	opt          *option          // Options shared by every shard
+	stats        stats.Recorder   // Statistics of this shard
+	sync.RWMutex                  // Lock for concurrent access
 }
 
 // item represents a single cached value with its expiration time.
 type item struct {
-	value      any   // The stored value
-	Expiration int64 // Unix nano timestamp when the item expires (0 = no expiration)
+	value      any    // The stored value
+	Expiration int64  // Unix nano timestamp when the item expires (0 = no expiration)
+	key        string // The key of the item, used to remove it when evicted
+	size       int64  // Estimated size in bytes, only set when a byte limit is configured
+	index      int    // Position in the expiry heap of the shard, -1 if not scheduled
+	slide      slide  // Idle expiration, zero unless stored with PutSliding
+	prev, next *item  // Neighbours in the order of an LRU or FIFO policy, nil otherwise
+}
+
+// Jitter describes how much random extra time is added to a TTL.
+// See WithTTLJitter for details.
+type Jitter = ttl.Jitter
+
+// LogLevels holds the level each kind of log record is written at.
+// See WithLogLevels for details.
+type LogLevels = logx.Levels
+
+// Clock provides the current time and the tickers of the janitors.
+// See WithClock for details.
+type Clock = clock.Clock
+
+// Option is a function type that configures the option struct.
+type Option func(*option)
+
+// option holds configuration parameters for the memory cache.
+type option struct {
+	jitter           Jitter
+	prefixes         *stats.Prefixes
+	latencySample    uint32
+	onEvicted        func(key string, value any, reason EvictReason)
+	maxEntries       int
+	newPolicy        func(capacity int) Policy
+	maxBytes         int64
+	sizer            Sizer
+	logger           *slog.Logger
+	logLevels        LogLevels
+	slowThreshold    time.Duration
+	shards           int
+	shardCapacity    int
+	janitor          time.Duration
+	seed             maphash.Seed
+	seeded           bool
+	log              *logx.Logger // Built from the logging options by Init
+	clock            Clock
+	codec            Codec
+	snapshotPath     string
+	snapshotInterval time.Duration
+	snapshots        *snapshotter // Started by Init when WithSnapshot is given
+	prefixIndex      bool
+	copyMode         CopyMode
+	cloner           Cloner
+	closed           atomic.Bool // Set by Close
+}
+
+// EvictReason describes why an item was removed from the cache.
+type EvictReason int
+
+const (
+	// Expired means the item was removed because its TTL elapsed
+	Expired EvictReason = iota
+	// Evicted means the item was removed to make room for another one
+	Evicted
+	// Replaced means the value was overwritten by Put or Forever
+	Replaced
+	// Deleted means the item was removed by Forget
+	Deleted
+	// Flushed means the item was removed by Flush
+	Flushed
+)
+
+// String returns the lower-case name of the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Evicted:
+		return "evicted"
+	case Replaced:
+		return "replaced"
+	case Deleted:
+		return "deleted"
+	case Flushed:
+		return "flushed"
+	default:
+		return "unknown"
+	}
+}
+
+// WithTTLJitter returns an Option that adds a random amount of extra time to
+// every TTL passed to Put and Add, so that keys written in bulk with the same
+// TTL do not all expire in the same second. Items without expiration are not affected.
+//
+// Example:
+//
+//	// Spread expirations by up to 10% of the TTL, but never more than 30 seconds
+//	cache := mem.Init(mem.WithTTLJitter(mem.Jitter{Fraction: 0.1, Max: 30 * time.Second}))
+func WithTTLJitter(j Jitter) Option {
+	return func(o *option) {
+		o.jitter = j
+	}
+}
+
+// WithStatsPrefix returns an Option that additionally groups statistics by key prefix.
+// The function maps a key to its group; keys mapped to "" are only counted in the totals.
+//
+// Example:
+//
+//	// Report "user:42" and "user:43" under the "user" prefix
+//	cache := mem.Init(mem.WithStatsPrefix(stats.PrefixUntil(":")))
+func WithStatsPrefix(fn stats.PrefixFunc) Option {
+	return func(o *option) {
+		o.prefixes = stats.NewPrefixes(fn)
+	}
+}
+
+// WithLatencySampleRate returns an Option that times one operation in n for the
+// latency histograms (default DefaultLatencySampleRate). Use 1 to time every
+// operation, or 0 to disable latency histograms altogether.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithLatencySampleRate(1))
+func WithLatencySampleRate(n int) Option {
+	return func(o *option) {
+		o.latencySample = uint32(max(n, 0))
+	}
+}
+
+// WithOnEvicted returns an Option that registers a function called for every
+// value leaving the cache, with the reason it left: expired by the janitor,
+// evicted by a size limit, replaced by Put or Forever (unless the same value is
+// stored again), deleted by Forget or removed by Flush. Pull does not report
+// the value, as it is handed to the caller.
+// The function is called outside the shard lock, so it may safely use the cache.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithOnEvicted(func(key string, value any, reason mem.EvictReason) {
+//	    if f, ok := value.(*os.File); ok {
+//	        f.Close()
+//	    }
+//	}))
+func WithOnEvicted(fn func(key string, value any, reason EvictReason)) Option {
+	return func(o *option) {
+		o.onEvicted = fn
+	}
+}
+
+// WithMaxEntries returns an Option that bounds the number of items in the cache.
+// The limit is split evenly across the shards, each holding at most n/shards
+// items (rounded up, see WithShards). When a shard is full, storing a new key evicts an item chosen
+// by the eviction policy, by default the least recently used one; Get and every
+// write count as a use, Has does not.
+// Evictions are counted in the statistics and reported to the eviction callback.
+// A non-positive n leaves the cache unbounded, which is the default.
+//
+// Example:
+//
+//	// Keep at most about 100k items
+//	cache := mem.Init(mem.WithMaxEntries(100_000))
+func WithMaxEntries(n int) Option {
+	return func(o *option) {
+		o.maxEntries = max(n, 0)
+	}
+}
+
+// WithEvictionPolicy returns an Option that selects the built-in policy a cache
+// bounded with WithMaxEntries or WithMaxBytes uses to choose the items to evict
+// (default LRU). TinyLFU resists one-off scans polluting the cache at the cost
+// of a small frequency sketch per shard; it counts missed lookups too, so a key
+// requested often is admitted once it is stored.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(100_000), mem.WithEvictionPolicy(mem.TinyLFU))
+func WithEvictionPolicy(p EvictionPolicy) Option {
+	return func(o *option) {
+		o.newPolicy = p.constructor()
+	}
+}
+
+// WithPolicy returns an Option that sets the function creating the eviction
+// policy of every shard of a bounded cache, e.g. a custom Policy. It receives
+// the number of items a shard may hold, or 0 when only WithMaxBytes is set.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(100_000), mem.WithPolicy(func(capacity int) mem.Policy {
+//	    return newClockPolicy(capacity)
+//	}))
+func WithPolicy(fn func(capacity int) Policy) Option {
+	return func(o *option) {
+		o.newPolicy = fn
+	}
+}
+
+// WithMaxBytes returns an Option that bounds the estimated memory used by the
+// items of the cache. The budget is shared by all shards: the shard storing an
+// item evicts the victims of its eviction policy first, then the other shards
+// are reclaimed in turn until the cache is back under budget. Sizes are
+// estimated with the Sizer set by WithSizer, or given per item with PutWithCost.
+// Storing a value larger than the whole budget fails with ErrTooLarge.
+// A non-positive n disables the limit, which is the default.
+//
+// Example:
+//
+//	// Keep about 256 MiB of values
+//	cache := mem.Init(mem.WithMaxBytes(256 << 20))
+func WithMaxBytes(n int64) Option {
+	return func(o *option) {
+		o.maxBytes = max(n, 0)
+	}
+}
+
+// WithSizer returns an Option that sets the function estimating the size of
+// items for WithMaxBytes (default DefaultSizer). A custom Sizer avoids the
+// reflection used by EstimateSize for values whose size is known.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxBytes(64<<20), mem.WithSizer(func(key string, value any) int64 {
+//	    return int64(len(key) + value.(*Image).Len())
+//	}))
+func WithSizer(s Sizer) Option {
+	return func(o *option) {
+		o.sizer = s
+	}
+}
+
+// WithLogger returns an Option that writes structured logs of janitor sweeps
+// and slow operations to l. Nothing is logged by default.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithLogger(slog.Default()))
+func WithLogger(l *slog.Logger) Option {
+	return func(o *option) {
+		o.logger = l
+	}
+}
+
+// WithLogLevels returns an Option that sets the level each kind of log record
+// is written at. By default sweeps are logged at debug level and slow
+// operations as warnings.
+//
+// Example:
+//
+//	levels := mem.DefaultLogLevels()
+//	levels.Sweep = slog.LevelInfo
+//	cache := mem.Init(mem.WithLogger(logger), mem.WithLogLevels(levels))
+func WithLogLevels(levels LogLevels) Option {
+	return func(o *option) {
+		o.logLevels = levels
+	}
+}
+
+// WithSlowThreshold returns an Option that logs operations taking longer than d
+// (default DefaultSlowThreshold, 0 disables). Only operations whose latency
+// is sampled (see WithLatencySampleRate) are checked.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithLogger(logger), mem.WithSlowThreshold(time.Millisecond))
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *option) {
+		o.slowThreshold = d
+	}
+}
+
+// WithShards returns an Option that sets the number of shards of the cache
+// (default DefaultShards). More shards reduce lock contention under heavy
+// concurrent writes; fewer shards make WithMaxEntries closer to a global limit.
+// n is rounded up to a power of two, and values below 1 keep the default.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithShards(256))
+func WithShards(n int) Option {
+	return func(o *option) {
+		if n < 1 {
+			n = DefaultShards
+		}
+		o.shards = 1 << bits.Len(uint(n-1))
+	}
+}
+
+// WithShardCapacity returns an Option that sets the initial capacity of the map
+// of each shard (default DefaultShardCapacity). Sizing it for the expected number
+// of items per shard avoids growing the maps while the cache fills up.
+// A negative n is treated as 0.
+//
+// Example:
+//
+//	// About one million items spread over 32 shards
+//	cache := mem.Init(mem.WithShardCapacity(1_000_000 / mem.DefaultShards))
+func WithShardCapacity(n int) Option {
+	return func(o *option) {
+		o.shardCapacity = max(n, 0)
+	}
+}
+
+// WithJanitorInterval returns an Option that sets how often each shard scans
+// its items to remove the expired ones (default DefaultJanitorInterval).
+// Reads never return expired items, so the janitors only reclaim the memory of
+// items that are not read again. A non-positive d disables them: no goroutine
+// is started, and expired items are only removed when they are read,
+// overwritten, deleted or evicted.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithJanitorInterval(time.Minute))
+func WithJanitorInterval(d time.Duration) Option {
+	return func(o *option) {
+		o.janitor = max(d, 0)
+	}
+}
+
+// WithHashSeed returns an Option that sets the seed of the hash mapping keys to
+// shards. By default every cache uses a random seed, so that keys crafted to
+// collide cannot all pile onto one shard; a fixed seed makes the distribution
+// of keys across shards reproducible.
+//
+// Example:
+//
+//	seed := maphash.MakeSeed()
+//	a := mem.Init(mem.WithHashSeed(seed))
+//	b := mem.Init(mem.WithHashSeed(seed)) // Same key, same shard index as in a
+func WithHashSeed(seed maphash.Seed) Option {
+	return func(o *option) {
+		o.seed = seed
+		o.seeded = true
+	}
+}
+
+// WithClock returns an Option that sets the clock used to compute expiration
+// times, check whether items have expired and tick the janitors (default
+// clock.System). A fake clock, such as clocktest.Fake, makes TTLs testable
+// without sleeping. Latencies are always measured with the system clock.
+// A nil c keeps the default.
+//
+// Example:
+//
+//	clk := clocktest.NewFake(time.Now())
+//	cache := mem.Init(mem.WithClock(clk))
+//	cache.Put("session", token, 60)
+//	clk.Advance(time.Minute + time.Second) // "session" has expired
+func WithClock(c Clock) Option {
+	return func(o *option) {
+		if c != nil {
+			o.clock = c
+		}
+	}
+}
+
+// WithPrefixIndex returns an Option that keeps the keys of every shard in a
+// radix tree, so that ForgetPrefix, ForgetPattern, Keys and Scan visit only the
+// keys starting with the prefix (or the literal start of the pattern) instead
+// of every item. The tree costs memory and time on every insert and removal,
+// so it only pays off when keys share prefixes that are deleted or listed often.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithPrefixIndex())
+//	cache.ForgetPrefix("user:42:") // Visits the keys of user 42 only
+func WithPrefixIndex() Option {
+	return func(o *option) {
+		o.prefixIndex = true
+	}
+}
+
+// DefaultLogLevels returns the log levels used unless WithLogLevels is given.
+func DefaultLogLevels() LogLevels {
+	return logx.DefaultLevels()
 }
 
 // Init creates and initializes a new in-memory cache.
-// It creates multiple cache shards and sets up janitors for each shard
-// to clean up expired items.
+// It creates multiple cache shards (see WithShards) and sets up janitors for
+// each shard to clean up expired items (see WithJanitorInterval).
+//
+// Parameters:
+//   - opts: A variadic list of Option functions to configure the cache
 //
 // Returns:
 //   - Cache: An initialized cache ready for use
@@ -44,26 +455,109 @@ type item struct {
 //
 //	cache := mem.Init()
 //	cache.Put("key", "value", 60) // Store for 60 seconds
-func Init() Cache {
-	const itemCount = 256 // Initial capacity for each shard's map
+func Init(opts ...Option) Cache {
+	opt := &option{
+		latencySample: DefaultLatencySampleRate,
+		logLevels:     logx.DefaultLevels(),
+		slowThreshold: DefaultSlowThreshold,
+		shards:        DefaultShards,
+		shardCapacity: DefaultShardCapacity,
+		janitor:       DefaultJanitorInterval,
+		clock:         clock.System,
+		codec:         GobCodec{},
+	}
+	// Apply all provided options to the option struct
+	for _, f := range opts {
+		f(opt)
+	}
+	opt.log = logx.New(opt.logger, opt.logLevels, opt.slowThreshold)
+	if opt.newPolicy == nil {
+		opt.newPolicy = NewLRU
+	}
+	if !opt.seeded {
+		opt.seed = maphash.MakeSeed()
+	}
 
 	// Create the cache with the specified number of shards
-	c := make(Cache, cacheGroupCount)
-	for i := 0; i < cacheGroupCount; i++ {
+	// Split the item limit across the shards, rounding up
+	capacity := (opt.maxEntries + opt.shards - 1) / opt.shards
+
+	// Share the byte limit between all shards
+	var b *budget
+	if opt.maxBytes > 0 {
+		b = &budget{max: opt.maxBytes, sizer: opt.sizer}
+		if b.sizer == nil {
+			b.sizer = DefaultSizer
+		}
+	}
+
+	c := make(Cache, opt.shards)
+	for i := range c {
 		// Initialize each shard with its own map
-		c[i] = &cache{items: make(map[string]item, itemCount)}
+		c[i] = &cache{items: make(map[string]*item, opt.shardCapacity), opt: opt, capacity: capacity, budget: b, index: newRadix(opt.prefixIndex)}
+		if capacity > 0 || b != nil {
+			c[i].resetPolicy()
+		}
 
+		if opt.janitor <= 0 {
+			continue
+		}
 		// Start a janitor for each shard to clean up expired items
-		runJanitor(c[i], time.Second)
-		// Set finalizer to ensure janitor is stopped when cache is garbage collected
-		runtime.SetFinalizer(c[i], stopJanitor)
+		runJanitor(c[i], opt.janitor)
 	}
 
+	// Warm the cache up from the last snapshot, if enabled
+	c.restoreSnapshot()
+
 	return c
 }
 
+// Close stops the janitors and periodic snapshots of the cache, saves a last
+// snapshot if WithSnapshot is set, and drops the items without reporting them
+// to the eviction callback. Afterwards every operation returns ErrClosed, Has
+// reports false, and Stats and ShardLens keep working.
+// Closing a cache more than once is a no-op.
+//
+// Returns:
+//   - error: Any error encountered while saving the last snapshot
+//
+// Example:
+//
+//	cache := mem.Init()
+//	defer cache.Close()
+func (c Cache) Close() error {
+	if len(c) == 0 || !c[0].opt.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	var err error
+	c.stopSnapshots()
+	if path := c[0].opt.snapshotPath; path != "" {
+		err = c.snapshot(path)
+	}
+
+	for _, group := range c {
+		if group.janitor != nil {
+			stopJanitor(group)
+		}
+
+		group.Lock()
+		group.items = make(map[string]*item)
+		group.expiries = nil
+		group.index.reset()
+		if group.bounded() {
+			group.resetPolicy()
+		}
+		group.account(-group.bytes)
+		group.Unlock()
+	}
+
+	return err
+}
+
 // getGroup returns the appropriate cache shard for the given key.
-// It uses a hash function to determine which shard should handle the key.
+// It hashes the key with the seed of the cache; the number of shards is a
+// power of two, so the low bits of the hash select the shard.
 //
 // Parameters:
 //   - key: The cache key to determine the shard for
@@ -71,7 +565,81 @@ func Init() Cache {
 // Returns:
 //   - *cache: The cache shard responsible for the key
 func (c Cache) getGroup(key string) *cache {
-	return c[uint(fnv32(key))%uint(cacheGroupCount)]
+	return c[maphash.String(c[0].opt.seed, key)&uint64(len(c)-1)]
+}
+
+// startTimer returns the start time of an operation whose latency is sampled,
+// and the zero time otherwise.
+func (g *cache) startTimer() time.Time {
+	if n := g.opt.latencySample; n > 0 && (n == 1 || rand.Uint32()%n == 0) {
+		return time.Now()
+	}
+	return time.Time{}
+}
+
+// record records the outcome of an operation on key in the shard statistics
+// and, when enabled, in the statistics of the key prefix. The latency is only
+// recorded if start was set by startTimer.
+func (g *cache) record(key string, op stats.Op, start time.Time, o stats.Outcome) {
+	prefix := g.opt.prefixes.For(key)
+	g.stats.Count(op, o)
+	prefix.Count(op, o)
+
+	if !start.IsZero() {
+		d := time.Since(start)
+		g.stats.Observe(op, d)
+		prefix.Observe(op, d)
+		g.opt.log.Slow("mem", op.String(), key, d)
+	}
+}
+
+// ShardLens returns the number of items stored in each shard, including
+// expired items that have not been removed by the janitor yet.
+//
+// Returns:
+//   - []int: The item count of every shard, indexed by shard number
+//
+// Example:
+//
+//	for i, n := range cache.ShardLens() {
+//	    fmt.Printf("shard %d: %d items\n", i, n)
+//	}
+func (c Cache) ShardLens() []int {
+	lens := make([]int, len(c))
+	for i, group := range c {
+		group.RLock()
+		lens[i] = len(group.items)
+		group.RUnlock()
+	}
+
+	return lens
+}
+
+// Stats returns the statistics of the cache, merged across all shards.
+// Counters are updated atomically per shard, so the snapshot is cheap to take
+// but not a consistent point-in-time view across shards. The usage is taken
+// with Usage, which briefly read-locks every shard in turn.
+//
+// Returns:
+//   - stats.Snapshot: Hits, misses, writes, deletes, errors, evictions, latencies
+//     and the usage of every shard
+//
+// Example:
+//
+//	s := cache.Stats()
+//	fmt.Printf("hit ratio: %.2f, items: %d\n", s.HitRatio(), s.Usage.Items)
+func (c Cache) Stats() stats.Snapshot {
+	var s stats.Snapshot
+	for _, group := range c {
+		s.Merge(group.stats.Snapshot())
+	}
+
+	if len(c) > 0 {
+		s.Prefixes = c[0].opt.prefixes.Snapshot()
+	}
+	s.Usage = c.Usage()
+
+	return s
 }
 
 // Put stores a value in the cache with the specified expiration time.
@@ -83,35 +651,130 @@ func (c Cache) getGroup(key string) *cache {
 //   - seconds: The time-to-live in seconds (0 for no expiration)
 //
 // Returns:
-//   - error: Always nil (for interface compatibility)
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
 //
 // Example:
 //
 //	cache.Put("user:123", userData, 3600) // Store for 1 hour
 func (c Cache) Put(key string, value any, seconds int) error {
-	var e int64
-	// Calculate expiration time if seconds > 0
-	if seconds > 0 {
-		e = time.Now().Add(time.Duration(seconds) * time.Second).UnixNano()
+	group := c.getGroup(key)
+	return c.put(group, stats.OpPut, key, value, seconds, group.opt.jitter, group.sizeOf(key, value))
+}
+
+// PutWithCost stores a value like Put, but uses the given size instead of
+// estimating it with the Sizer. It is only meaningful with WithMaxBytes.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - seconds: The time-to-live in seconds (0 for no expiration)
+//   - cost: The size of the item in bytes
+//
+// Returns:
+//   - error: ErrTooLarge if the cost exceeds the byte budget, ErrUncopyable if the value
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	cache.PutWithCost("thumb:42", img, 3600, int64(len(img.Pix)))
+func (c Cache) PutWithCost(key string, value any, seconds int, cost int64) error {
+	group := c.getGroup(key)
+	return c.put(group, stats.OpPut, key, value, seconds, group.opt.jitter, cost)
+}
+
+// PutWithJitter stores a value like Put, but uses the given jitter instead of
+// the one configured with WithTTLJitter. Pass a zero Jitter to disable it for this call.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - seconds: The time-to-live in seconds (0 for no expiration)
+//   - j: The jitter applied to this TTL
+//
+// Returns:
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	cache.PutWithJitter("user:123", userData, 3600, mem.Jitter{Max: time.Minute})
+func (c Cache) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	group := c.getGroup(key)
+	return c.put(group, stats.OpPut, key, value, seconds, j, group.sizeOf(key, value))
+}
+
+// put stores a value of the given size in the given shard with a jittered
+// expiration time, and records it as op.
+func (c Cache) put(group *cache, op stats.Op, key string, value any, seconds int, j Jitter, size int64) error {
+	if group.opt.closed.Load() {
+		return ErrClosed
 	}
 
-	// Create the cache item
-	data := item{
-		value:      value,
-		Expiration: e,
+	value, err := group.copyIn(value)
+	if err != nil {
+		group.record(key, op, time.Time{}, stats.Error)
+		return err
 	}
 
-	// Get the appropriate shard and store the item
-	group := c.getGroup(key)
+	return c.set(group, op, key, value, group.expiration(seconds, j), size, slide{})
+}
+
+// set stores a value of the given size in the given shard until the Unix nano
+// expiration time exp (0 for no expiration), extended by s on every Get, and
+// records it as op.
+func (c Cache) set(group *cache, op stats.Op, key string, value any, exp, size int64, s slide) error {
+	if group.opt.closed.Load() {
+		return ErrClosed
+	}
+
+	start := group.startTimer()
+
+	// A value that can never fit replaces nothing, but the old value is stale
+	if !group.budget.fits(size) {
+		group.Lock()
+		old, gone := group.lookup(key)
+		if old != nil {
+			group.remove(old)
+		}
+		group.Unlock()
+
+		if old != nil {
+			group.notify(key, old.value, Replaced)
+		}
+		group.expire(gone)
+		group.record(key, op, start, stats.Error)
+		return ErrTooLarge
+	}
+
+	// Store the item in the shard
 	group.Lock()
-	group.items[key] = data
+	// Keep the old value for the eviction callback, called once the lock is released
+	var old any
+	var replaced bool
+	var gone *item
+	if group.opt.onEvicted != nil {
+		var i *item
+		if i, gone = group.lookup(key); i != nil {
+			old, replaced = i.value, !sameValue(i.value, value)
+		}
+	}
+	evicted := group.store(key, value, exp, size, s)
 	group.Unlock()
 
+	if replaced {
+		group.notify(key, old, Replaced)
+	}
+	group.expire(gone)
+	group.evict(evicted)
+	c.reclaim(group)
+	group.record(key, op, start, stats.Write)
+
 	return nil
 }
 
 // Add adds a value to the cache only if the key does not already exist.
-// If the key exists, the operation is a no-op.
+// If the key exists, the operation is a no-op. An expired key counts as absent.
 //
 // Parameters:
 //   - key: The key under which to store the value
@@ -119,7 +782,8 @@ func (c Cache) Put(key string, value any, seconds int) error {
 //   - seconds: The time-to-live in seconds (0 for no expiration)
 //
 // Returns:
-//   - error: Always nil (for interface compatibility)
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
 //
 // Example:
 //
@@ -127,36 +791,116 @@ func (c Cache) Put(key string, value any, seconds int) error {
 //	cache.Add("user:123", userData, 3600)
 func (c Cache) Add(key string, value any, seconds int) error {
 	group := c.getGroup(key)
+	_, err := c.add(group, key, value, seconds, group.opt.jitter)
+	return err
+}
+
+// AddWithJitter adds a value like Add, but uses the given jitter instead of
+// the one configured with WithTTLJitter. Pass a zero Jitter to disable it for this call.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - seconds: The time-to-live in seconds (0 for no expiration)
+//   - j: The jitter applied to this TTL
+//
+// Returns:
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	cache.AddWithJitter("user:123", userData, 3600, mem.Jitter{Fraction: 0.05})
+func (c Cache) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	_, err := c.add(c.getGroup(key), key, value, seconds, j)
+	return err
+}
+
+// TryAdd adds a value like Add and reports whether it was stored, i.e. whether
+// the key was absent. The check and the write happen under the shard lock.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - seconds: The time-to-live in seconds (0 for no expiration)
+//
+// Returns:
+//   - bool: true if the value was stored, false if the key exists or on error
+//   - error: The errors of Add
+//
+// Example:
+//
+//	if added, _ := cache.TryAdd("lock:job", owner, 30); added {
+//	    // This caller holds the lock
+//	}
+func (c Cache) TryAdd(key string, value any, seconds int) (bool, error) {
+	group := c.getGroup(key)
+	return c.add(group, key, value, seconds, group.opt.jitter)
+}
+
+// TryAddWithJitter adds a value like AddWithJitter and reports whether it was
+// stored, see TryAdd.
+func (c Cache) TryAddWithJitter(key string, value any, seconds int, j Jitter) (bool, error) {
+	return c.add(c.getGroup(key), key, value, seconds, j)
+}
+
+// add stores a value in the given shard only if the key does not already
+// exist, and reports whether it was stored.
+func (c Cache) add(group *cache, key string, value any, seconds int, j Jitter) (bool, error) {
+	if group.opt.closed.Load() {
+		return false, ErrClosed
+	}
+
+	start := group.startTimer()
+	size := group.sizeOf(key, value)
+	if !group.budget.fits(size) {
+		group.record(key, stats.OpAdd, start, stats.Error)
+		return false, ErrTooLarge
+	}
+
+	value, err := group.copyIn(value)
+	if err != nil {
+		group.record(key, stats.OpAdd, start, stats.Error)
+		return false, err
+	}
+
 	group.Lock()
 
-	// Check if the key already exists
-	_, ok := group.items[key]
+	// Check if the key already exists, an expired one does not count
+	var evicted []*item
+	i, gone := group.lookup(key)
+	ok := i != nil
 	if !ok {
 		// Key doesn't exist, add it with expiration if specified
-		var e int64
-		if seconds > 0 {
-			e = time.Now().Add(time.Duration(seconds) * time.Second).UnixNano()
-		}
-
-		group.items[key] = item{
-			value:      value,
-			Expiration: e,
-		}
+		evicted = group.store(key, value, group.expiration(seconds, j), size, slide{})
 	}
 	group.Unlock()
 
-	return nil
+	group.expire(gone)
+	group.evict(evicted)
+	c.reclaim(group)
+
+	outcome := stats.None
+	if !ok {
+		outcome = stats.Write
+	}
+	group.record(key, stats.OpAdd, start, outcome)
+
+	return !ok, nil
 }
 
 // Get retrieves a value from the cache.
-// If the key does not exist, it returns nil without an error.
+// If the key does not exist or has expired, it returns nil without an error.
+// An expired item is removed right away instead of at the next janitor sweep,
+// and the expiration of an item stored with PutSliding is extended.
 //
 // Parameters:
 //   - key: The key to retrieve
 //
 // Returns:
 //   - any: The retrieved value, or nil if not found
-//   - error: Always nil (for interface compatibility)
+//   - error: ErrClosed after Close, ErrUncopyable if the value cannot be copied
+//     for WithCopyMode, nil otherwise
 //
 // Example:
 //
@@ -166,17 +910,61 @@ func (c Cache) Add(key string, value any, seconds int) error {
 //	}
 func (c Cache) Get(key string) (any, error) {
 	group := c.getGroup(key)
-	group.RLock()
+	if group.opt.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	start := group.startTimer()
+
+	var value any
+	if group.bounded() {
+		// Reading is reported to the eviction policy, which needs the write lock
+		group.Lock()
+		i, gone := group.lookup(key)
+		ok := i != nil
+		if ok {
+			group.touch(i)
+			group.extend(i)
+			value = i.value
+		} else {
+			group.miss(key)
+		}
+		group.Unlock()
 
-	// Get the item from the cache (returns zero value if not found)
-	i, _ := group.items[key]
+		group.expire(gone)
+		value, err := group.copyOut(value)
+		group.record(key, stats.OpGet, start, hitOrMiss(ok))
+		return value, err
+	}
+
+	group.RLock()
+	// Get the item from the cache, an expired one is a miss
+	i, ok := group.items[key]
+	expired := ok && group.expired(i)
+	sliding := false
+	if ok && !expired {
+		value = i.value
+		sliding = i.slide.idle > 0
+	}
 	group.RUnlock()
 
-	return i.value, nil
+	// Remove the expired item rather than waiting for the janitor
+	if expired {
+		group.reap(i)
+	}
+	// Moving the expiration of a sliding item needs the write lock
+	if sliding {
+		group.refresh(i)
+	}
+	value, err := group.copyOut(value)
+	group.record(key, stats.OpGet, start, hitOrMiss(ok && !expired))
+
+	return value, err
 }
 
 // Pull retrieves a value from the cache and then removes it.
-// This is equivalent to calling Get followed by Forget.
+// This is equivalent to calling Get followed by Forget, but done under a single lock.
+// An expired key counts as absent.
 //
 // Parameters:
 //   - key: The key to retrieve and remove
@@ -190,22 +978,36 @@ func (c Cache) Get(key string) (any, error) {
 //	// Get the value and remove it in one operation
 //	value, _ := cache.Pull("user:123")
 func (c Cache) Pull(key string) (any, error) {
-	// Get the value first
-	value, err := c.Get(key)
-	if err != nil {
-		return nil, err
+	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return nil, ErrClosed
 	}
 
-	// Then delete the key
-	_, err = c.Forget(key)
-	if err != nil {
-		return nil, err
+	start := group.startTimer()
+	group.Lock()
+
+	// Get the value first, then delete the key
+	var value any
+	i, gone := group.lookup(key)
+	ok := i != nil
+	if ok {
+		value = i.value
+		group.remove(i)
+	}
+	group.Unlock()
+
+	group.expire(gone)
+
+	outcome := stats.Miss
+	if ok {
+		outcome = stats.Hit | stats.Delete
 	}
+	group.record(key, stats.OpPull, start, outcome)
 
 	return value, nil
 }
 
-// Has checks if a key exists in the cache.
+// Has checks if a key exists in the cache and has not expired.
 //
 // Parameters:
 //   - key: The key to check
@@ -220,13 +1022,25 @@ func (c Cache) Pull(key string) (any, error) {
 //	}
 func (c Cache) Has(key string) bool {
 	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return false
+	}
+
+	start := group.startTimer()
 	group.RLock()
 
-	// Check if the key exists in the map
-	_, ok := group.items[key]
+	// Check if the key exists in the map, an expired one does not count
+	i, ok := group.items[key]
+	expired := ok && group.expired(i)
 	group.RUnlock()
 
-	return ok
+	// Remove the expired item rather than waiting for the janitor
+	if expired {
+		group.reap(i)
+	}
+	group.record(key, stats.OpHas, start, stats.None)
+
+	return ok && !expired
 }
 
 // Forever stores a value in the cache indefinitely (without expiration).
@@ -237,46 +1051,60 @@ func (c Cache) Has(key string) bool {
 //   - value: The value to store
 //
 // Returns:
-//   - error: Always nil (for interface compatibility)
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
 //
 // Example:
 //
 //	cache.Forever("app:config", configData)
 func (c Cache) Forever(key string, value any) error {
 	group := c.getGroup(key)
-	group.Lock()
-	// Store with no expiration (Expiration = 0)
-	group.items[key] = item{value: value}
-	group.Unlock()
-
-	return nil
+	return c.put(group, stats.OpForever, key, value, 0, Jitter{}, group.sizeOf(key, value))
 }
 
 // Forget removes a key from the cache.
+// An expired item counts as absent: it is removed and reported as Expired.
 //
 // Parameters:
 //   - key: The key to remove
 //
 // Returns:
-//   - bool: Always true (for interface compatibility)
-//   - error: Always nil (for interface compatibility)
+//   - bool: true if a live item was removed, false otherwise
+//   - error: ErrClosed after Close, nil otherwise
 //
 // Example:
 //
 //	removed, _ := cache.Forget("user:123")
 func (c Cache) Forget(key string) (bool, error) {
 	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return false, ErrClosed
+	}
+
+	start := group.startTimer()
 
 	group.Lock()
-	// Remove the key from the map
-	delete(group.items, key)
+	// Remove the key from the map, an expired one is removed by lookup
+	i, gone := group.lookup(key)
+	ok := i != nil
+	if ok {
+		group.remove(i)
+	}
 	group.Unlock()
 
-	return true, nil
+	group.expire(gone)
+	outcome := stats.None
+	if ok {
+		outcome = stats.Delete
+		group.notify(key, i.value, Deleted)
+	}
+	group.record(key, stats.OpForget, start, outcome)
+
+	return ok, nil
 }
 
 // Increment atomically increments the integer value of a key by the given amount.
-// If the key does not exist, it is set to the amount.
+// If the key does not exist or has expired, it is set to the amount.
 // If the value is not an integer, an error is returned.
 //
 // Parameters:
@@ -293,35 +1121,49 @@ func (c Cache) Forget(key string) (bool, error) {
 //	// newValue is the updated counter
 func (c Cache) Increment(key string, n int) (int, error) {
 	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	start := group.startTimer()
 
-	// Use defer to ensure the lock is released even if an error occurs
-	defer group.Unlock()
 	group.Lock()
 
-	// Check if the key exists
-	v, ok := group.items[key]
-	if !ok {
+	// Check if the key exists, an expired one does not count
+	v, gone := group.lookup(key)
+	if v == nil {
 		// Key doesn't exist, create it with the increment value
-		group.items[key] = item{value: n}
+		evicted := group.store(key, n, 0, group.sizeOf(key, n), slide{})
+		group.Unlock()
+
+		group.expire(gone)
+		group.evict(evicted)
+		c.reclaim(group)
+		group.record(key, stats.OpIncrement, start, stats.Write)
 		return n, nil
 	}
 
 	// Check if the value is an integer
 	nv, ok := v.value.(int)
 	if !ok {
+		group.Unlock()
+		group.record(key, stats.OpIncrement, start, stats.Error)
 		return 0, fmt.Errorf("Invalid type: expected int, got %T", v.value)
 	}
 
 	// Increment the value
 	nv += n
 	v.value = nv
-	group.items[key] = v
+	group.touch(v)
+	group.Unlock()
+
+	group.record(key, stats.OpIncrement, start, stats.Write)
 
 	return nv, nil
 }
 
 // Decrement atomically decrements the integer value of a key by the given amount.
-// If the key does not exist, an error is returned.
+// If the key does not exist or has expired, an error is returned.
 // If the value is not an integer, an error is returned.
 //
 // Parameters:
@@ -338,27 +1180,38 @@ func (c Cache) Increment(key string, n int) (int, error) {
 //	// newValue is the updated counter
 func (c Cache) Decrement(key string, n int) (int, error) {
 	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	start := group.startTimer()
 
-	// Use defer to ensure the lock is released even if an error occurs
-	defer group.Unlock()
 	group.Lock()
 
-	// Check if the key exists
-	v, ok := group.items[key]
-	if !ok {
+	// Check if the key exists, an expired one does not count
+	v, gone := group.lookup(key)
+	if v == nil {
+		group.Unlock()
+		group.expire(gone)
+		group.record(key, stats.OpDecrement, start, stats.Error)
 		return n, errors.New("Undefined key: " + key)
 	}
 
 	// Check if the value is an integer
 	nv, ok := v.value.(int)
 	if !ok {
+		group.Unlock()
+		group.record(key, stats.OpDecrement, start, stats.Error)
 		return 0, errors.New("Invalid type ")
 	}
 
 	// Decrement the value
 	nv -= n
 	v.value = nv
-	group.items[key] = v
+	group.touch(v)
+	group.Unlock()
+
+	group.record(key, stats.OpDecrement, start, stats.Write)
 
 	return nv, nil
 }
@@ -367,39 +1220,83 @@ func (c Cache) Decrement(key string, n int) (int, error) {
 // This operation clears all shards.
 //
 // Returns:
-//   - error: Always nil (for interface compatibility)
+//   - error: ErrClosed after Close, nil otherwise
 //
 // Example:
 //
 //	cache.Flush() // Clear the entire cache
 func (c Cache) Flush() error {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return ErrClosed
+	}
+
+	start := time.Now()
+
 	// Iterate through all shards
 	for _, group := range c {
 		group.Lock()
 
-		// Clear the map
-		clear(group.items)
+		// Clear the map, or swap it for an empty one if its items are reported
+		items := group.items
+		n := len(items)
+		if group.opt.onEvicted != nil {
+			group.items = make(map[string]*item, len(items))
+		} else {
+			clear(group.items)
+		}
+		group.expiries = nil
+		group.index.reset()
+		if group.bounded() {
+			group.resetPolicy()
+		}
+		group.account(-group.bytes)
 
 		group.Unlock()
+
+		group.stats.Delete(n)
+		if group.opt.onEvicted != nil {
+			for key, i := range items {
+				group.notify(key, i.value, Flushed)
+			}
+		}
+	}
+
+	if len(c) > 0 {
+		c[0].stats.Record(stats.OpFlush, time.Since(start), stats.None)
 	}
 
 	return nil
 }
 
-// fnv32 is a hash function for strings based on the FNV algorithm.
-// It's used to determine which cache shard should handle a given key.
-//
-// Parameters:
-//   - key: The string to hash
-//
-// Returns:
-//   - uint32: The hash value
-func fnv32(key string) uint32 {
-	hash := uint32(2166136261)       // FNV offset basis
-	const prime32 = uint32(16777619) // FNV prime
-	for i := 0; i < len(key); i++ {
-		hash *= prime32
-		hash ^= uint32(key[i])
-	}
-	return hash
+// sameValue reports whether a and b are the same comparable value, e.g. the
+// same pointer stored again. Values of uncomparable types are never the same.
+func sameValue(a, b any) bool {
+	t := reflect.TypeOf(a)
+	if t == nil || t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+
+	defer func() {
+		// Comparable types may still hold uncomparable values in interface fields
+		_ = recover()
+	}()
+	return a == b
+}
+
+// hitOrMiss returns the lookup outcome for a found or missing key.
+func hitOrMiss(found bool) stats.Outcome {
+	if found {
+		return stats.Hit
+	}
+	return stats.Miss
+}
+
+// expiration converts a TTL in seconds into a Unix nano expiration timestamp,
+// applying the given jitter. It returns 0 (no expiration) when seconds <= 0.
+func (g *cache) expiration(seconds int, j Jitter) int64 {
+	if seconds <= 0 {
+		return 0
+	}
+
+	return g.opt.clock.Now().Add(j.Apply(time.Duration(seconds) * time.Second)).UnixNano()
 }
@@ -1,14 +1,26 @@
 package mem
 
 import (
+	"bytes"
+	"errors"
+	"hash/maphash"
+	"log/slog"
+	"math/rand"
+	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+	"github.com/sk-pkg/cache/stats"
 )
 
 func TestMemCache(t *testing.T) {
-	c := Init()
+	clk := clocktest.NewFake(time.Now())
+	c := Init(WithClock(clk))
 
 	a, err := c.Get("a")
 	if a != nil {
@@ -35,7 +47,7 @@ func TestMemCache(t *testing.T) {
 		t.Error("Got a failed value from mem cache:", a)
 	}
 
-	time.Sleep(2 * time.Second)
+	clk.Advance(2 * time.Second)
 	a, err = c.Get("a")
 	if err != nil {
 		t.Error(err)
@@ -141,7 +153,7 @@ func TestMemCache(t *testing.T) {
 	}
 
 	// Check if the permanent cache still exists after waiting for a period of time
-	time.Sleep(2 * time.Second)
+	clk.Advance(2 * time.Second)
 	foreverVal, err = c.Get("forever")
 	if err != nil || foreverVal == nil || foreverVal.(string) != "value" {
 		t.Error("Forever value should persist:", foreverVal, err)
@@ -210,7 +222,8 @@ func TestConcurrentAccess(t *testing.T) {
 
 // Test persistence of Forever method
 func TestForeverPersistence(t *testing.T) {
-	c := Init()
+	clk := clocktest.NewFake(time.Now())
+	c := Init(WithClock(clk))
 
 	err := c.Forever("persistent", 42)
 	if err != nil {
@@ -219,7 +232,7 @@ func TestForeverPersistence(t *testing.T) {
 
 	// Simulate time passing
 	for i := 0; i < 5; i++ {
-		time.Sleep(500 * time.Millisecond)
+		clk.Advance(time.Hour)
 		val, err := c.Get("persistent")
 		if err != nil || val == nil || val.(int) != 42 {
 			t.Errorf("Forever value should persist after %d checks: %v, %v", i, val, err)
@@ -227,6 +240,680 @@ func TestForeverPersistence(t *testing.T) {
 	}
 }
 
+// Test TTL jitter with a deterministic random source
+func TestTTLJitter(t *testing.T) {
+	c := Init(WithTTLJitter(Jitter{Fraction: 0.5, Rand: func() float64 { return 1 }}))
+
+	expiresIn := func(key string) time.Duration {
+		group := c.getGroup(key)
+		group.RLock()
+		defer group.RUnlock()
+		return time.Until(time.Unix(0, group.items[key].Expiration))
+	}
+
+	_ = c.Put("put", 1, 100)
+	if d := expiresIn("put"); d < 149*time.Second || d > 150*time.Second {
+		t.Error("Put should apply the configured jitter, expires in:", d)
+	}
+
+	_ = c.Add("add", 1, 100)
+	if d := expiresIn("add"); d < 149*time.Second || d > 150*time.Second {
+		t.Error("Add should apply the configured jitter, expires in:", d)
+	}
+
+	_ = c.PutWithJitter("override", 1, 100, Jitter{})
+	if d := expiresIn("override"); d < 99*time.Second || d > 100*time.Second {
+		t.Error("PutWithJitter should override the configured jitter, expires in:", d)
+	}
+
+	_ = c.Put("forever", 1, 0)
+	if e := c.getGroup("forever").items["forever"].Expiration; e != 0 {
+		t.Error("Jitter should not add an expiration to permanent items:", e)
+	}
+}
+
+func TestStats(t *testing.T) {
+	c := Init(WithStatsPrefix(stats.PrefixUntil(":")), WithLatencySampleRate(1))
+
+	_ = c.Put("user:1", 1, 10)
+	_ = c.Add("user:1", 2, 10)
+	_, _ = c.Get("user:1")
+	_, _ = c.Get("user:2")
+	_, _ = c.Pull("user:1")
+	_, _ = c.Decrement("missing", 1)
+	_ = c.Forever("config", "value")
+	_ = c.Flush()
+
+	s := c.Stats()
+	if s.Hits != 2 || s.Misses != 1 || s.Writes != 2 || s.Deletes != 2 || s.Errors != 1 {
+		t.Errorf("Unexpected counters: %+v", s)
+	}
+
+	if h := s.Latency[stats.OpGet]; h.Count != 2 {
+		t.Error("Expected 2 get latency observations, got:", h.Count)
+	}
+
+	if user := s.Prefixes["user"]; user.Hits != 2 || user.Misses != 1 || user.Writes != 1 {
+		t.Errorf("Unexpected prefix counters: %+v", user)
+	}
+}
+
+func TestOnEvictedExpired(t *testing.T) {
+	evicted := make(chan string, 1)
+	clk := clocktest.NewFake(time.Now())
+	var c Cache
+	c = Init(WithClock(clk), WithOnEvicted(func(key string, value any, reason EvictReason) {
+		// The callback runs outside the shard lock, so it may use the cache
+		if !c.Has(key) && reason == Expired {
+			evicted <- key
+		}
+	}))
+
+	_ = c.Put("a", 1, 1)
+	// The item is due at the second tick of the janitors
+	clk.Advance(2 * time.Second)
+
+	select {
+	case key := <-evicted:
+		if key != "a" {
+			t.Error("Unexpected evicted key:", key)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expired items should be reported to the eviction callback")
+	}
+
+	if s := c.Stats(); s.Expirations != 1 {
+		t.Error("Expected 1 expiration, got:", s.Expirations)
+	}
+}
+
+func TestLazyExpiration(t *testing.T) {
+	for name, opt := range map[string]Option{"unbounded": WithMaxEntries(0), "bounded": WithMaxEntries(1000)} {
+		t.Run(name, func(t *testing.T) {
+			clk := clocktest.NewFake(time.Unix(1_000_000, 0))
+			var mu sync.Mutex
+			var expired []string
+			c := Init(opt, WithClock(clk), WithJanitorInterval(0), WithOnEvicted(func(key string, value any, reason EvictReason) {
+				mu.Lock()
+				defer mu.Unlock()
+				if reason == Expired {
+					expired = append(expired, key)
+				}
+			}))
+			items := func() int {
+				n := 0
+				for _, l := range c.ShardLens() {
+					n += l
+				}
+				return n
+			}
+
+			for _, key := range []string{"get", "has", "pull", "add", "incr", "decr", "put"} {
+				_ = c.Put(key, 1, 1)
+			}
+			clk.Advance(time.Second)
+			if v, _ := c.Get("get"); v != 1 || !c.Has("has") {
+				t.Fatal("Items should live for exactly their TTL")
+			}
+
+			clk.Advance(time.Nanosecond)
+			if v, _ := c.Get("get"); v != nil {
+				t.Error("Expected an expired item to be a miss, got:", v)
+			}
+			if c.Has("has") {
+				t.Error("Expected Has to report false for an expired item")
+			}
+			if v, _ := c.Pull("pull"); v != nil {
+				t.Error("Expected Pull to miss an expired item, got:", v)
+			}
+			_ = c.Add("add", 2, 0)
+			if !c.Has("add") {
+				t.Error("Expected Add to store over an expired item")
+			}
+			if n, _ := c.Increment("incr", 5); n != 5 {
+				t.Error("Expected Increment to restart from an expired item, got:", n)
+			}
+			if _, err := c.Decrement("decr", 1); err == nil {
+				t.Error("Expected Decrement to fail on an expired item")
+			}
+			_ = c.Put("put", 2, 0)
+
+			// Every expired item was removed by the read that found it
+			if n := items(); n != 3 {
+				t.Error("Expected only the rewritten items to be left, got:", n)
+			}
+			mu.Lock()
+			slices.Sort(expired)
+			if want := []string{"add", "decr", "get", "has", "incr", "pull", "put"}; !slices.Equal(expired, want) {
+				t.Errorf("Expected %v to be reported as expired, got %v", want, expired)
+			}
+			mu.Unlock()
+			if s := c.Stats(); s.Expirations != 7 {
+				t.Error("Expected 7 expirations, got:", s.Expirations)
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1_000_000, 0))
+	c := Init(WithShards(1), WithClock(clk), WithJanitorInterval(0))
+	g := c[0]
+
+	// More items are due than a single batch holds
+	const n = 3 * sweepBatch
+	for i := 0; i < n; i++ {
+		_ = c.Put(strconv.Itoa(i), i, 1+i%3)
+	}
+	_ = c.Forever("forever", 1)
+	_ = c.Forever("0", 0) // No longer expires
+	_ = c.Put("1", 1, 1)  // Now due first
+	_, _ = c.Forget("3")  // Gone before it expires
+	_ = c.Put("forever", 1, 10)
+
+	// Every item that expires sits at its position in the heap
+	checkHeap := func() {
+		t.Helper()
+		scheduled := 0
+		for key, it := range g.items {
+			if it.Expiration == 0 {
+				if it.index != -1 {
+					t.Fatalf("Item %q does not expire but is scheduled at %d", key, it.index)
+				}
+				continue
+			}
+			scheduled++
+			if it.index < 0 || g.expiries[it.index] != it {
+				t.Fatalf("Item %q is not at its position %d in the expiry heap", key, it.index)
+			}
+		}
+		if scheduled != len(g.expiries) {
+			t.Fatalf("Expected %d scheduled items, got %d", scheduled, len(g.expiries))
+		}
+	}
+	checkHeap()
+
+	if removed := g.sweep(); removed != 0 {
+		t.Fatal("Nothing is due yet, but the sweep removed:", removed)
+	}
+
+	clk.Advance(time.Second + time.Nanosecond)
+	// A third of the items, without "0" and "3" but with "1"
+	if removed := g.sweep(); removed != n/3-1 {
+		t.Errorf("Expected %d items due after 1s, got %d", n/3-1, removed)
+	}
+	checkHeap()
+	if c.Has("1") || c.Has("6") || !c.Has("0") || !c.Has("4") {
+		t.Error("The sweep removed the wrong items")
+	}
+
+	clk.Advance(time.Hour)
+	g.sweep()
+	checkHeap()
+	if lens := c.ShardLens(); lens[0] != 1 || !c.Has("0") {
+		t.Error("Expected only the item without expiration to be left, got items:", lens[0])
+	}
+	if s := c.Stats(); s.Expirations != n-1 {
+		t.Errorf("Expected %d expirations, got %d", n-1, s.Expirations)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestForgetExpired(t *testing.T) {
+	var reasons []EvictReason
+	clk := clocktest.NewFake(time.Unix(1000, 0))
+	c := Init(WithShards(1), WithClock(clk), WithJanitorInterval(0), WithOnEvicted(func(key string, value any, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}))
+	defer c.Close()
+
+	_ = c.Put("a", 1, 10)
+	_ = c.Put("b", 2, 0)
+	clk.Advance(11 * time.Second)
+
+	// An expired item counts as absent, like on every other read path
+	if removed, _ := c.Forget("a"); removed {
+		t.Error("Expected Forget to report an expired item as absent")
+	}
+	if removed, _ := c.Forget("missing"); removed {
+		t.Error("Expected Forget to report a missing item as absent")
+	}
+	if removed, _ := c.Forget("b"); !removed {
+		t.Error("Expected Forget to report the removal of a live item")
+	}
+
+	if len(reasons) != 2 || reasons[0] != Expired || reasons[1] != Deleted {
+		t.Error("Expected the expired item to be reported as expired, got:", reasons)
+	}
+	if s := c.Stats(); s.Deletes != 1 || s.Expirations != 1 {
+		t.Errorf("Expected 1 delete and 1 expiration, got %d and %d", s.Deletes, s.Expirations)
+	}
+}
+
+func TestOnEvictedReasons(t *testing.T) {
+	type removal struct {
+		key    string
+		value  any
+		reason EvictReason
+	}
+
+	var removed []removal
+	var c Cache
+	// A single item fits in the cache
+	c = Init(WithShards(1), WithMaxEntries(1), WithOnEvicted(func(key string, value any, reason EvictReason) {
+		// The callback runs outside the shard lock, so it may use the cache
+		_ = c.Has(key)
+		removed = append(removed, removal{key, value, reason})
+	}))
+
+	expect := func(want ...removal) {
+		t.Helper()
+		if len(removed) != len(want) {
+			t.Fatalf("Expected %v, got %v", want, removed)
+		}
+		for i := range want {
+			if removed[i] != want[i] {
+				t.Errorf("Expected %v, got %v", want[i], removed[i])
+			}
+		}
+		removed = nil
+	}
+
+	_ = c.Put("a", 1, 0)
+	_ = c.Put("a", 2, 0)
+	expect(removal{"a", 1, Replaced})
+
+	// Storing the same value again does not replace it
+	_ = c.Forever("a", 2)
+	expect()
+
+	_, _ = c.Forget("a")
+	expect(removal{"a", 2, Deleted})
+
+	// Uncomparable values are always replaced
+	_ = c.Put("s", []int{1}, 0)
+	_ = c.Put("s", []int{1}, 0)
+	if len(removed) != 1 || removed[0].reason != Replaced {
+		t.Error("Expected an uncomparable value to be replaced, got:", removed)
+	}
+	_, _ = c.Forget("s")
+	removed = nil
+
+	// Pulled values belong to the caller
+	_ = c.Put("p", 3, 0)
+	_, _ = c.Pull("p")
+	expect()
+
+	_ = c.Put("k0", 0, 0)
+	_ = c.Put("k1", 1, 0)
+	expect(removal{"k0", 0, Evicted})
+
+	_ = c.Flush()
+	expect(removal{"k1", 1, Flushed})
+}
+
+func TestLogger(t *testing.T) {
+	var out syncBuffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	clk := clocktest.NewFake(time.Now())
+	c := Init(WithClock(clk), WithLogger(logger), WithLatencySampleRate(1), WithSlowThreshold(time.Nanosecond))
+
+	_ = c.Put("a", 1, 1)
+	if !strings.Contains(out.String(), "msg=\"slow cache operation\" store=mem operation=put key=a") {
+		t.Error("Expected a slow operation record, got:", out.String())
+	}
+
+	// The janitors sweep in the background once they tick
+	clk.Advance(2 * time.Second)
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), "msg=\"cache janitor sweep\" store=mem expired=1") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a janitor sweep record, got:", out.String())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	var evicted []string
+	c := Init(WithMaxEntries(2*DefaultShards), WithOnEvicted(func(key string, value any, reason EvictReason) {
+		if reason == Evicted {
+			evicted = append(evicted, key)
+		}
+	}))
+
+	// Find three keys stored in the same shard, which holds two items
+	var keys []string
+	group := c.getGroup("k0")
+	for i := 0; len(keys) < 3; i++ {
+		if key := "k" + strconv.Itoa(i); c.getGroup(key) == group {
+			keys = append(keys, key)
+		}
+	}
+
+	_ = c.Put(keys[0], 0, 0)
+	_ = c.Put(keys[1], 1, 0)
+	// Reading keys[0] makes keys[1] the least recently used item
+	_, _ = c.Get(keys[0])
+	_ = c.Put(keys[2], 2, 0)
+
+	if !c.Has(keys[0]) || c.Has(keys[1]) || !c.Has(keys[2]) {
+		t.Error("The least recently used item should have been evicted")
+	}
+
+	if len(evicted) != 1 || evicted[0] != keys[1] {
+		t.Error("Expected the eviction to be reported, got:", evicted)
+	}
+
+	if s := c.Stats(); s.Evictions != 1 {
+		t.Error("Expected 1 eviction, got:", s.Evictions)
+	}
+
+	// Removing an item frees its slot
+	_, _ = c.Forget(keys[0])
+	_ = c.Put(keys[1], 1, 0)
+	if !c.Has(keys[1]) || !c.Has(keys[2]) || c.Stats().Evictions != 1 {
+		t.Error("Forgotten items should not count towards the limit")
+	}
+
+	for i := 0; i < 1000; i++ {
+		_ = c.Put(strconv.Itoa(i), i, 0)
+	}
+
+	for i, n := range c.ShardLens() {
+		if n > 2 {
+			t.Errorf("Shard %d holds %d items, expected at most 2", i, n)
+		}
+	}
+}
+
+func TestShardOptions(t *testing.T) {
+	for _, tt := range []struct{ n, want int }{{0, DefaultShards}, {1, 1}, {5, 8}, {64, 64}} {
+		if got := len(Init(WithShards(tt.n)).ShardLens()); got != tt.want {
+			t.Errorf("WithShards(%d) created %d shards, expected %d", tt.n, got, tt.want)
+		}
+	}
+
+	// The same seed maps a key to the same shard index
+	seed := maphash.MakeSeed()
+	clk := clocktest.NewFake(time.Now())
+	a := Init(WithShards(16), WithHashSeed(seed), WithJanitorInterval(0), WithClock(clk))
+	b := Init(WithShards(16), WithHashSeed(seed), WithShardCapacity(0))
+	for i := 0; i < 1000; i++ {
+		_ = a.Put(strconv.Itoa(i), i, 0)
+		_ = b.Put(strconv.Itoa(i), i, 0)
+	}
+	if la, lb := a.ShardLens(), b.ShardLens(); !slices.Equal(la, lb) {
+		t.Errorf("Expected the same distribution with the same seed, got %v and %v", la, lb)
+	}
+	for i, n := range a.ShardLens() {
+		if n == 0 {
+			t.Errorf("Shard %d is empty after storing 1000 keys", i)
+		}
+	}
+
+	// Without janitors, expired items stay until they are removed
+	if a[0].janitor != nil {
+		t.Error("Expected no janitor when the interval is 0")
+	}
+	_ = a.Put("expired", 1, 1)
+	clk.Advance(time.Hour)
+	total := 0
+	for _, n := range a.ShardLens() {
+		total += n
+	}
+	if total != 1001 {
+		t.Error("Expected the expired item to be kept without a janitor, got items:", total)
+	}
+}
+
+// goroutines returns the stack traces of the goroutines that contain s, e.g. the
+// function that created them.
+func goroutines(s string) []string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var found []string
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, s) {
+			found = append(found, g)
+		}
+	}
+	return found
+}
+
+func TestClose(t *testing.T) {
+	const janitor = "created by github.com/sk-pkg/cache/mem.runJanitor"
+	// Janitors of caches created by other tests are still running
+	running := len(goroutines(janitor))
+
+	var evicted []EvictReason
+	c := Init(WithShards(4), WithMaxEntries(100), WithOnEvicted(func(key string, value any, reason EvictReason) {
+		evicted = append(evicted, reason)
+	}))
+	_ = c.Put("a", 1, 0)
+	_ = c.Put("b", 2, 60)
+	if n := len(goroutines(janitor)) - running; n != 4 {
+		t.Fatal("Expected a janitor per shard, got:", n)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	// Close waits for the janitors, so none of them may be left
+	if leaked := goroutines(janitor); len(leaked) != running {
+		t.Fatalf("%d janitors still running after Close:\n%s", len(leaked)-running, strings.Join(leaked, "\n\n"))
+	}
+	if err := c.Close(); err != nil {
+		t.Error("Closing twice should be a no-op, got:", err)
+	}
+
+	if len(evicted) != 0 {
+		t.Error("Close should not report the dropped items, got:", evicted)
+	}
+	for i, n := range c.ShardLens() {
+		if n != 0 {
+			t.Errorf("Shard %d still holds %d items after Close", i, n)
+		}
+	}
+
+	checks := map[string]error{
+		"Put":       c.Put("a", 1, 0),
+		"Add":       c.Add("c", 1, 0),
+		"Forever":   c.Forever("a", 1),
+		"Flush":     c.Flush(),
+		"Increment": func() error { _, err := c.Increment("n", 1); return err }(),
+		"Decrement": func() error { _, err := c.Decrement("n", 1); return err }(),
+		"Get":       func() error { _, err := c.Get("a"); return err }(),
+		"Pull":      func() error { _, err := c.Pull("a"); return err }(),
+		"Forget":    func() error { _, err := c.Forget("a"); return err }(),
+	}
+	for op, err := range checks {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected %s to return ErrClosed, got: %v", op, err)
+		}
+	}
+	if c.Has("a") {
+		t.Error("Has should report false after Close")
+	}
+
+	// Closing a cache without janitors starts and stops nothing
+	if err := Init(WithJanitorInterval(0)).Close(); err != nil {
+		t.Error("Close failed:", err)
+	}
+}
+
+func TestEstimateSize(t *testing.T) {
+	type user struct {
+		Name  string
+		Tags  []string
+		Score int64
+	}
+
+	shared := &user{Name: "shared"}
+
+	tests := []struct {
+		name  string
+		value any
+		min   int64
+		max   int64
+	}{
+		{"nil", nil, 0, 0},
+		{"string", "hello", 5, 5},
+		{"bytes", make([]byte, 1024), 1024, 1024},
+		{"int", 42, 8, 8},
+		{"struct", user{Name: "abc", Tags: []string{"x", "yz"}}, 48 + 3 + 32 + 3, 48 + 3 + 32 + 3},
+		{"large slice", make([]int64, 1000), 8000, 8100},
+		{"map", map[string]int{"a": 1, "bc": 2}, 2*24 + 3, 200},
+		{"shared pointers", []*user{shared, shared}, 24 + 16 + 48 + 6, 24 + 16 + 48 + 6},
+	}
+
+	for _, tt := range tests {
+		if n := EstimateSize(tt.value); n < tt.min || n > tt.max {
+			t.Errorf("%s: expected a size in [%d, %d], got %d", tt.name, tt.min, tt.max, n)
+		}
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	c := Init(WithMaxBytes(10_000), WithSizer(func(key string, value any) int64 {
+		return int64(len(value.(string)))
+	}))
+
+	value := strings.Repeat("x", 1000)
+	for i := 0; i < 100; i++ {
+		if err := c.Put(strconv.Itoa(i), value, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var n int
+	for _, l := range c.ShardLens() {
+		n += l
+	}
+
+	if n > 10 {
+		t.Error("Expected at most 10 items within the budget, got:", n)
+	}
+
+	if s := c.Stats(); s.Evictions != uint64(100-n) {
+		t.Errorf("Expected %d evictions, got: %d", 100-n, s.Evictions)
+	}
+
+	// The most recent item survives even if it is the only one in its shard
+	if !c.Has("99") {
+		t.Error("The last stored item should not be evicted")
+	}
+
+	// A large value evicts items of other shards
+	if err := c.PutWithCost("large", value, 0, 9_500); err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Has("large") || c.getGroup("large").budget.used.Load() > 10_000 {
+		t.Error("Storing a large value should reclaim space from every shard")
+	}
+
+	if err := c.Put("huge", strings.Repeat("x", 20_000), 0); !errors.Is(err, ErrTooLarge) {
+		t.Error("Expected ErrTooLarge, got:", err)
+	}
+
+	_ = c.Flush()
+	if used := c.getGroup("large").budget.used.Load(); used != 0 {
+		t.Error("Flush should release the whole budget, got:", used)
+	}
+}
+
+func TestTinyLFUScanResistance(t *testing.T) {
+	hot := func(c Cache) int {
+		// Build a frequently used working set
+		for round := 0; round < 5; round++ {
+			for i := 0; i < 1000; i++ {
+				key := "hot:" + strconv.Itoa(i)
+				if v, _ := c.Get(key); v == nil {
+					_ = c.Put(key, i, 0)
+				}
+			}
+		}
+
+		// Scan through many keys that are used only once
+		for i := 0; i < 100000; i++ {
+			_ = c.Put("scan:"+strconv.Itoa(i), i, 0)
+		}
+
+		n := 0
+		for i := 0; i < 1000; i++ {
+			if c.Has("hot:" + strconv.Itoa(i)) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if n := hot(Init(WithMaxEntries(3200))); n > 100 {
+		t.Error("Expected the scan to flush the working set out of the LRU cache, kept:", n)
+	}
+
+	c := Init(WithMaxEntries(3200), WithEvictionPolicy(TinyLFU))
+	if n := hot(c); n < 900 {
+		t.Error("Expected TinyLFU to keep the working set during a scan, kept:", n)
+	}
+
+	for i, n := range c.ShardLens() {
+		if n > 100 {
+			t.Errorf("Shard %d holds %d items, expected at most 100", i, n)
+		}
+	}
+
+	if s := c.Stats(); s.Evictions == 0 {
+		t.Error("Expected evictions to be counted")
+	}
+}
+
+func TestTinyLFUMaxBytes(t *testing.T) {
+	c := Init(WithMaxBytes(1000), WithEvictionPolicy(TinyLFU), WithSizer(func(string, any) int64 {
+		return 10
+	}))
+
+	for i := 0; i < 1000; i++ {
+		_ = c.Put(strconv.Itoa(i), i, 0)
+	}
+
+	if used := c[0].budget.used.Load(); used > 1000 {
+		t.Error("Expected the cache to stay within its budget, used:", used)
+	}
+
+	_ = c.Flush()
+	_ = c.Put("a", 1, 0)
+	if v, _ := c.Get("a"); v != 1 {
+		t.Error("Expected the cache to be usable after a flush, got:", v)
+	}
+}
+
 func BenchmarkMemPut(b *testing.B) {
 	c := Init()
 	for i := 0; i < b.N; i++ {
@@ -340,7 +1027,19 @@ func BenchmarkForever(b *testing.B) {
 
 // Add concurrent benchmark test
 func BenchmarkConcurrentAccess(b *testing.B) {
-	c := Init()
+	benchmarkConcurrentAccess(b)
+}
+
+func BenchmarkConcurrentAccessLRU(b *testing.B) {
+	benchmarkConcurrentAccess(b, WithMaxEntries(100000))
+}
+
+func BenchmarkConcurrentAccessTinyLFU(b *testing.B) {
+	benchmarkConcurrentAccess(b, WithMaxEntries(100000), WithEvictionPolicy(TinyLFU))
+}
+
+func benchmarkConcurrentAccess(b *testing.B, opts ...Option) {
+	c := Init(opts...)
 	b.ResetTimer()
 
 	b.RunParallel(func(pb *testing.PB) {
@@ -356,7 +1055,19 @@ func BenchmarkConcurrentAccess(b *testing.B) {
 
 // Test cache performance with different sizes
 func BenchmarkLargeCache(b *testing.B) {
-	c := Init()
+	benchmarkLargeCache(b)
+}
+
+func BenchmarkLargeCacheLRU(b *testing.B) {
+	benchmarkLargeCache(b, WithMaxEntries(100000))
+}
+
+func BenchmarkLargeCacheTinyLFU(b *testing.B) {
+	benchmarkLargeCache(b, WithMaxEntries(100000), WithEvictionPolicy(TinyLFU))
+}
+
+func benchmarkLargeCache(b *testing.B, opts ...Option) {
+	c := Init(opts...)
 	// Prefill with large amount of data
 	for i := 0; i < 100000; i++ {
 		c.Put(strconv.Itoa(i), i, 60)
@@ -370,3 +1081,45 @@ func BenchmarkLargeCache(b *testing.B) {
 		c.Get(key)
 	}
 }
+
+func BenchmarkMemPutBounded(b *testing.B) {
+	c := Init(WithMaxEntries(10_000))
+	for i := 0; i < b.N; i++ {
+		c.Put(strconv.Itoa(i), i, 10)
+	}
+}
+
+func BenchmarkPolicyHitRatio(b *testing.B) {
+	for _, p := range []EvictionPolicy{LRU, TinyLFU, LFU, FIFO, ARC} {
+		b.Run(p.String(), func(b *testing.B) {
+			c := Init(WithMaxEntries(10000), WithEvictionPolicy(p))
+			zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, 1_000_000)
+			hits := 0
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := strconv.FormatUint(zipf.Uint64(), 10)
+				if v, _ := c.Get(key); v != nil {
+					hits++
+				} else {
+					_ = c.Put(key, i, 0)
+				}
+			}
+
+			b.ReportMetric(float64(hits)/float64(b.N)*100, "hit%")
+		})
+	}
+}
+
+func BenchmarkSweep(b *testing.B) {
+	// A million items of which none is due: the sweep should not visit them
+	c := Init(WithShards(1), WithJanitorInterval(0))
+	for i := 0; i < 1_000_000; i++ {
+		_ = c.Put(strconv.Itoa(i), i, 3600)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c[0].sweep()
+	}
+}
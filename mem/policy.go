@@ -0,0 +1,224 @@
+package mem
+
+// Policy decides which item of a bounded shard is evicted next.
+// Every shard owns its own Policy, created with the function passed to WithPolicy.
+// Methods are called with the shard's write lock held, so implementations need
+// no synchronization, but they must be fast and must not use the cache.
+// A policy may also implement MissObserver.
+type Policy interface {
+	// OnInsert is called after a new key was stored
+	OnInsert(key string)
+	// OnAccess is called after a stored key was read or overwritten
+	OnAccess(key string)
+	// OnRemove is called after a key was removed: deleted, expired, flushed or evicted
+	OnRemove(key string)
+	// Victim returns the key to evict next, or false if there is none.
+	// The shard removes the key and calls OnRemove for it.
+	Victim() (key string, ok bool)
+}
+
+// MissObserver is implemented by policies that also learn from lookups of
+// keys that are not stored, such as TinyLFU.
+type MissObserver interface {
+	// OnMiss is called after a Get found nothing for key
+	OnMiss(key string)
+}
+
+// EvictionPolicy names a built-in Policy.
+// See WithEvictionPolicy for details.
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used item
+	LRU EvictionPolicy = iota
+	// TinyLFU admits and evicts items by their estimated access frequency (W-TinyLFU):
+	// new items enter a small LRU window, then have to be accessed more often than
+	// the eviction candidate of the main region to stay in the cache
+	TinyLFU
+	// LFU evicts the least frequently used item, the least recently used one among equals
+	LFU
+	// FIFO evicts the oldest item, regardless of how it is used
+	FIFO
+	// ARC balances recency and frequency, adapting to the workload with the
+	// history of recently evicted keys (Adaptive Replacement Cache)
+	ARC
+)
+
+// String returns the name of the policy.
+func (p EvictionPolicy) String() string {
+	switch p {
+	case LRU:
+		return "lru"
+	case TinyLFU:
+		return "tinylfu"
+	case LFU:
+		return "lfu"
+	case FIFO:
+		return "fifo"
+	case ARC:
+		return "arc"
+	default:
+		return "unknown"
+	}
+}
+
+// constructor returns the function creating the policy for a shard.
+func (p EvictionPolicy) constructor() func(capacity int) Policy {
+	switch p {
+	case TinyLFU:
+		return NewTinyLFU
+	case LFU:
+		return NewLFU
+	case FIFO:
+		return NewFIFO
+	case ARC:
+		return NewARC
+	default:
+		return NewLRU
+	}
+}
+
+// itemPolicy is implemented by built-in policies that link the items of the
+// shard themselves, instead of tracking their keys in a map next to the items.
+// The shard calls these methods in place of the Policy methods.
+type itemPolicy interface {
+	insert(it *item)
+	access(it *item)
+	remove(it *item)
+	victim() *item
+}
+
+// resetPolicy replaces the policy of the shard with an empty one.
+// The caller must hold the write lock, or own the shard.
+func (g *cache) resetPolicy() {
+	g.policy = g.opt.newPolicy(g.capacity)
+	g.linked, _ = g.policy.(itemPolicy)
+}
+
+// bounded reports whether the shard has an item or byte limit and therefore an eviction policy.
+func (g *cache) bounded() bool {
+	return g.policy != nil
+}
+
+// full reports whether the shard holds more items than its capacity, or the
+// cache more bytes than its budget.
+func (g *cache) full() bool {
+	return (g.capacity > 0 && len(g.items) > g.capacity) || g.budget.over()
+}
+
+// store inserts or replaces the item of key and reports it to the policy.
+// While the shard is full, the victims of the policy are removed and returned
+// so that they can be reported once the lock is released. The policy may reject
+// the stored item itself, unless it is the only item of the shard: then the
+// byte budget is exceeded by other shards, which reclaim makes room in.
+// The caller must hold the write lock.
+func (g *cache) store(key string, value any, exp, size int64, s slide) []*item {
+	it, ok := g.items[key]
+	if ok {
+		g.account(size - it.size)
+		it.value = value
+		it.Expiration = exp
+		it.size = size
+		it.slide = s
+		g.schedule(it)
+		g.touch(it)
+	} else {
+		it = &item{key: key, value: value, Expiration: exp, size: size, index: -1, slide: s}
+		g.items[key] = it
+		g.index.insert(key)
+		g.schedule(it)
+		g.account(size)
+		if g.linked != nil {
+			g.linked.insert(it)
+		} else if g.bounded() {
+			g.policy.OnInsert(key)
+		}
+	}
+
+	if !g.bounded() {
+		return nil
+	}
+
+	var evicted []*item
+	for g.full() {
+		victim := g.victim()
+		if victim == nil || (victim == it && len(g.items) == 1) {
+			break
+		}
+		g.remove(victim)
+		evicted = append(evicted, victim)
+	}
+
+	return evicted
+}
+
+// victim returns the item the policy evicts next, or nil if there is none or
+// the policy names a key that is not stored. The caller must hold the write lock.
+func (g *cache) victim() *item {
+	if g.linked != nil {
+		return g.linked.victim()
+	}
+
+	key, ok := g.policy.Victim()
+	if !ok {
+		return nil
+	}
+	return g.items[key]
+}
+
+// touch reports an access of it to the policy. The caller must hold the write lock.
+func (g *cache) touch(it *item) {
+	if g.linked != nil {
+		g.linked.access(it)
+	} else if g.bounded() {
+		g.policy.OnAccess(it.key)
+	}
+}
+
+// miss reports a lookup of a missing key to the policy. The caller must hold the write lock.
+func (g *cache) miss(key string) {
+	if mo, ok := g.policy.(MissObserver); ok {
+		mo.OnMiss(key)
+	}
+}
+
+// remove deletes it from the shard. The caller must hold the write lock.
+func (g *cache) remove(it *item) {
+	delete(g.items, it.key)
+	g.index.delete(it.key)
+	g.unschedule(it)
+	g.account(-it.size)
+	if g.linked != nil {
+		g.linked.remove(it)
+	} else if g.bounded() {
+		g.policy.OnRemove(it.key)
+	}
+}
+
+// account adjusts the estimated bytes stored in the shard by delta.
+// The caller must hold the write lock.
+func (g *cache) account(delta int64) {
+	g.bytes += delta
+	g.budget.add(delta)
+}
+
+// evict counts items removed to make room for others and reports them to the
+// eviction callback. It must be called without holding the lock.
+func (g *cache) evict(evicted []*item) {
+	if len(evicted) == 0 {
+		return
+	}
+
+	g.stats.Evict(len(evicted))
+	for _, it := range evicted {
+		g.notify(it.key, it.value, Evicted)
+	}
+}
+
+// notify reports a value leaving the cache to the eviction callback, if any.
+// It must be called without holding the lock.
+func (g *cache) notify(key string, value any, reason EvictReason) {
+	if g.opt.onEvicted != nil {
+		g.opt.onEvicted(key, value, reason)
+	}
+}
@@ -0,0 +1,236 @@
+package mem
+
+import (
+	"strconv"
+	"testing"
+)
+
+// insert reports keys to p as newly stored.
+func insert(p Policy, keys ...string) {
+	for _, key := range keys {
+		p.OnInsert(key)
+	}
+}
+
+// victim returns the victim of p, failing the test if there is none.
+func victim(t *testing.T, p Policy) string {
+	t.Helper()
+	key, ok := p.Victim()
+	if !ok {
+		t.Fatal("Expected a victim")
+	}
+	return key
+}
+
+func TestPolicies(t *testing.T) {
+	for _, ep := range []EvictionPolicy{LRU, TinyLFU, LFU, FIFO, ARC} {
+		p := ep.constructor()(3)
+		insert(p, "a", "b", "c")
+
+		for _, key := range []string{"a", "b", "c"} {
+			p.OnRemove(key)
+		}
+
+		if key, ok := p.Victim(); ok {
+			t.Errorf("%s: expected no victim once every key is removed, got %q", ep, key)
+		}
+
+		// Removing and accessing unknown keys is harmless
+		p.OnRemove("unknown")
+		p.OnAccess("unknown")
+	}
+}
+
+func TestLRUAndFIFO(t *testing.T) {
+	lru, fifo := NewLRU(3), NewFIFO(3)
+	for _, p := range []Policy{lru, fifo} {
+		insert(p, "a", "b", "c")
+		p.OnAccess("a")
+	}
+
+	if key := victim(t, lru); key != "b" {
+		t.Error("LRU should evict the least recently used key, got:", key)
+	}
+
+	if key := victim(t, fifo); key != "a" {
+		t.Error("FIFO should evict the oldest key, got:", key)
+	}
+}
+
+func TestLFU(t *testing.T) {
+	p := NewLFU(3)
+	insert(p, "a", "b", "c")
+	p.OnAccess("a")
+	p.OnAccess("a")
+	p.OnAccess("c")
+
+	if key := victim(t, p); key != "b" {
+		t.Error("LFU should evict the least frequently used key, got:", key)
+	}
+
+	p.OnRemove("b")
+	if key := victim(t, p); key != "c" {
+		t.Error("LFU should find the next lowest count after a removal, got:", key)
+	}
+
+	// Among keys used equally often, the least recently used one is evicted
+	insert(p, "d", "e")
+	if key := victim(t, p); key != "d" {
+		t.Error("Expected the least recently used key among equals, got:", key)
+	}
+}
+
+func TestARC(t *testing.T) {
+	p := NewARC(2).(*arcPolicy)
+	insert(p, "a", "b")
+
+	// Evicting a key remembers it as a ghost
+	if key := victim(t, p); key != "a" {
+		t.Fatal("ARC should evict from T1 first, got:", key)
+	}
+	p.OnRemove("a")
+	if p.nodes["a"].region != arcB1 {
+		t.Fatal("An evicted key should be remembered in B1")
+	}
+
+	// Storing the ghost again grows the target size of T1 and counts as frequent use
+	p.OnInsert("a")
+	if p.target != 1 || p.nodes["a"].region != arcT2 {
+		t.Errorf("Expected target 1 and the key in T2, got target %d, region %d", p.target, p.nodes["a"].region)
+	}
+
+	// Deleting a key does not remember it
+	p.OnRemove("b")
+	if _, ok := p.nodes["b"]; ok {
+		t.Error("A deleted key should be forgotten")
+	}
+
+	// Ghosts are bounded by the capacity
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		p.OnInsert(key)
+		p.OnRemove(victim(t, p))
+	}
+	if len(p.nodes) > 4 {
+		t.Error("Expected at most twice the capacity of keys, got:", len(p.nodes))
+	}
+}
+
+// newestPolicy is a custom Policy evicting the most recently stored key.
+func TestTinyLFUGrowth(t *testing.T) {
+	p := NewTinyLFU(0).(*tinyLFU)
+	insert(p, "hot")
+	for range 10 {
+		p.OnAccess("hot")
+	}
+	hot, width := p.sketch.estimate("hot"), p.sketch.width()
+
+	// Without an item limit the sketch grows with the keys, keeping the counts
+	for i := range 4 * width {
+		p.OnInsert(strconv.Itoa(i))
+	}
+	if p.sketch.width() <= width {
+		t.Fatal("Expected the sketch to grow, got width:", p.sketch.width())
+	}
+	if n := p.sketch.estimate("hot"); n < hot {
+		t.Errorf("Expected the hot key to keep its count of %d across growth, got %d", hot, n)
+	}
+
+	if p.sketch.estimate("hot") <= p.sketch.estimate("0") {
+		t.Error("Expected the hot key to outrank a key inserted once after growth")
+	}
+}
+
+func TestLRUIntrusive(t *testing.T) {
+	for _, ep := range []EvictionPolicy{LRU, FIFO} {
+		c := Init(WithShards(1), WithMaxEntries(3), WithEvictionPolicy(ep))
+		for _, key := range []string{"a", "b", "c"} {
+			_ = c.Put(key, key, 0)
+		}
+		_, _ = c.Get("a")
+		_, _ = c.Forget("b")
+		_ = c.Put("d", "d", 0)
+		_ = c.Put("e", "e", 0)
+
+		want := "a"
+		if ep == LRU {
+			want = "c"
+		}
+		if c.Has(want) {
+			t.Errorf("%s: expected %q to be evicted", ep, want)
+		}
+
+		// The order is linked through the items, without a map of keys
+		if p := c[0].policy.(*lruPolicy); p.keys != nil || p.order.len != 3 {
+			t.Errorf("%s: expected the 3 items to be linked without keys, got %d linked and %d keys", ep, p.order.len, len(p.keys))
+		}
+		_ = c.Close()
+	}
+}
+
+type newestPolicy struct {
+	keys []string
+}
+
+func (p *newestPolicy) OnInsert(key string) { p.keys = append(p.keys, key) }
+func (p *newestPolicy) OnAccess(string)     {}
+
+func (p *newestPolicy) OnRemove(key string) {
+	for i, k := range p.keys {
+		if k == key {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *newestPolicy) Victim() (string, bool) {
+	if len(p.keys) == 0 {
+		return "", false
+	}
+	return p.keys[len(p.keys)-1], true
+}
+
+func TestWithPolicy(t *testing.T) {
+	var evicted []string
+	c := Init(
+		WithMaxEntries(2*DefaultShards),
+		WithPolicy(func(capacity int) Policy {
+			if capacity != 2 {
+				t.Error("Expected the capacity of a shard, got:", capacity)
+			}
+			return &newestPolicy{}
+		}),
+		WithOnEvicted(func(key string, value any, reason EvictReason) {
+			evicted = append(evicted, key)
+		}),
+	)
+
+	var keys []string
+	group := c.getGroup("k0")
+	for i := 0; len(keys) < 3; i++ {
+		if key := "k" + strconv.Itoa(i); c.getGroup(key) == group {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range keys {
+		_ = c.Put(key, key, 0)
+	}
+
+	// The policy rejected the newest key
+	if !c.Has(keys[0]) || !c.Has(keys[1]) || c.Has(keys[2]) {
+		t.Error("The cache should evict the victims of a custom policy")
+	}
+
+	if len(evicted) != 1 || evicted[0] != keys[2] {
+		t.Error("Expected the rejected key to be reported, got:", evicted)
+	}
+
+	// Flushing starts over with a new policy
+	_ = c.Flush()
+	_ = c.Put(keys[2], 2, 0)
+	if !c.Has(keys[2]) {
+		t.Error("Expected the cache to be usable after a flush")
+	}
+}
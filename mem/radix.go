@@ -0,0 +1,153 @@
+package mem
+
+import (
+	"slices"
+	"strings"
+)
+
+// radix is a radix tree over the keys of a shard, used to find the keys with a
+// given prefix without visiting every item. It is guarded by the shard lock.
+type radix struct {
+	root radixNode
+}
+
+// radixNode is a node of the tree. The key of a node is the concatenation of
+// the labels on the path from the root.
+type radixNode struct {
+	label    string       // The bytes of the key added by this node
+	leaf     bool         // Whether a key ends at this node
+	children []*radixNode // Sorted by the first byte of their label
+}
+
+// newRadix returns an empty tree if enabled, and nil otherwise.
+func newRadix(enabled bool) *radix {
+	if !enabled {
+		return nil
+	}
+	return &radix{}
+}
+
+// insert adds key to the tree. It accepts a nil tree.
+func (t *radix) insert(key string) {
+	if t == nil {
+		return
+	}
+
+	n := &t.root
+	for key != "" {
+		i, child := n.child(key[0])
+		if child == nil {
+			n.children = slices.Insert(n.children, i, &radixNode{label: key, leaf: true})
+			return
+		}
+
+		l := commonPrefix(child.label, key)
+		if l < len(child.label) {
+			// Split the edge where the key leaves it
+			split := &radixNode{label: child.label[:l], children: []*radixNode{child}}
+			child.label = child.label[l:]
+			n.children[i] = split
+			child = split
+		}
+		n, key = child, key[l:]
+	}
+	n.leaf = true
+}
+
+// delete removes key from the tree and merges the nodes it leaves with a
+// single child. It accepts a nil tree.
+func (t *radix) delete(key string) {
+	if t != nil {
+		t.root.remove(key)
+	}
+}
+
+// remove removes key, relative to the key of n, from the subtree of n.
+// It reports whether key was found.
+func (n *radixNode) remove(key string) bool {
+	if key == "" {
+		found := n.leaf
+		n.leaf = false
+		return found
+	}
+
+	i, child := n.child(key[0])
+	if child == nil || !strings.HasPrefix(key, child.label) || !child.remove(key[len(child.label):]) {
+		return false
+	}
+
+	if !child.leaf {
+		switch len(child.children) {
+		case 0:
+			n.children = slices.Delete(n.children, i, i+1)
+		case 1:
+			grandchild := child.children[0]
+			grandchild.label = child.label + grandchild.label
+			n.children[i] = grandchild
+		}
+	}
+
+	return true
+}
+
+// walkPrefix calls fn with every key of the tree starting with prefix.
+// fn must not modify the tree.
+func (t *radix) walkPrefix(prefix string, fn func(key string)) {
+	n, key := &t.root, ""
+	for prefix != "" {
+		_, child := n.child(prefix[0])
+		switch {
+		case child == nil:
+			return
+		case strings.HasPrefix(prefix, child.label):
+			prefix = prefix[len(child.label):]
+		case strings.HasPrefix(child.label, prefix):
+			prefix = ""
+		default:
+			return
+		}
+		n, key = child, key+child.label
+	}
+
+	n.walk(key, fn)
+}
+
+// walk calls fn with every key of the subtree of n, whose key is key.
+func (n *radixNode) walk(key string, fn func(key string)) {
+	if n.leaf {
+		fn(key)
+	}
+	for _, child := range n.children {
+		child.walk(key+child.label, fn)
+	}
+}
+
+// reset removes every key. It accepts a nil tree.
+func (t *radix) reset() {
+	if t != nil {
+		t.root = radixNode{}
+	}
+}
+
+// child returns the child of n whose label starts with b, or the position
+// where such a child would be inserted and nil.
+func (n *radixNode) child(b byte) (int, *radixNode) {
+	i, found := slices.BinarySearchFunc(n.children, b, func(c *radixNode, b byte) int {
+		return int(c.label[0]) - int(b)
+	})
+	if !found {
+		return i, nil
+	}
+	return i, n.children[i]
+}
+
+// commonPrefix returns the length of the common prefix of a and b.
+func commonPrefix(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
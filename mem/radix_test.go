@@ -0,0 +1,98 @@
+package mem
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRadix(t *testing.T) {
+	tree := newRadix(true)
+	keys := map[string]bool{}
+	r := rand.New(rand.NewSource(1))
+
+	// Short keys over a small alphabet share many prefixes and split many edges
+	randomKey := func() string {
+		var b strings.Builder
+		for range r.Intn(6) {
+			b.WriteByte("ab:"[r.Intn(3)])
+		}
+		return b.String()
+	}
+
+	for i := range 5000 {
+		key := randomKey()
+		if r.Intn(3) == 0 {
+			tree.delete(key)
+			delete(keys, key)
+		} else {
+			tree.insert(key)
+			keys[key] = true
+		}
+
+		if i%100 != 0 {
+			continue
+		}
+		for _, prefix := range []string{"", "a", "ab", "b:", "a:b", "ba:ab"} {
+			var got []string
+			tree.walkPrefix(prefix, func(key string) {
+				got = append(got, key)
+			})
+			var want []string
+			for key := range keys {
+				if strings.HasPrefix(key, prefix) {
+					want = append(want, key)
+				}
+			}
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("walkPrefix(%q) after %d operations = %v, want %v", prefix, i, got, want)
+			}
+		}
+	}
+
+	// Removing every key leaves an empty, fully compacted tree
+	for key := range keys {
+		tree.delete(key)
+	}
+	if tree.root.leaf || len(tree.root.children) != 0 {
+		t.Errorf("Expected an empty tree, got %+v", tree.root)
+	}
+}
+
+func TestRadixCompaction(t *testing.T) {
+	tree := newRadix(true)
+	tree.insert("user:1")
+	tree.insert("user:2")
+	tree.delete("user:2")
+
+	// The split edge is merged back once its sibling is gone
+	if len(tree.root.children) != 1 || tree.root.children[0].label != "user:1" {
+		t.Errorf("Expected a single edge user:1, got %+v", tree.root.children[0])
+	}
+
+	// Deleting a missing key or a prefix of a key changes nothing
+	tree.delete("user:")
+	tree.delete("user:12")
+	var got []string
+	tree.walkPrefix("user", func(key string) {
+		got = append(got, key)
+	})
+	if !slices.Equal(got, []string{"user:1"}) {
+		t.Error("Expected user:1 to remain, got:", got)
+	}
+
+	var disabled *radix
+	disabled.insert("a")
+	disabled.delete("a")
+	disabled.reset()
+}
+
+func BenchmarkRadixInsert(b *testing.B) {
+	tree := newRadix(true)
+	for i := 0; i < b.N; i++ {
+		tree.insert("user:" + strconv.Itoa(i%100000) + ":profile")
+	}
+}
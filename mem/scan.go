@@ -0,0 +1,201 @@
+package mem
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/stats"
+)
+
+// DefaultScanCount is the number of keys Scan aims to return when count is not positive.
+const DefaultScanCount = 10
+
+// Keys returns the keys matching a Redis-style glob pattern ('*', '?', '[...]'
+// and '\' escapes); an empty pattern matches every key. Expired items are
+// skipped. The shards are visited one at a time, so writes to other shards are
+// never blocked and the result is not a point-in-time view across shards.
+//
+// Parameters:
+//   - pattern: The glob pattern the keys must match
+//
+// Returns:
+//   - []string: The matching keys, in no particular order
+//   - error: ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	keys, err := cache.Keys("user:*")
+func (c Cache) Keys(pattern string) ([]string, error) {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	start := time.Now()
+
+	var keys []string
+	for _, group := range c {
+		keys = group.keys(pattern, group.opt.clock.Now().UnixNano(), keys)
+	}
+
+	if len(c) > 0 {
+		c[0].stats.Record(stats.OpScan, time.Since(start), stats.None)
+	}
+
+	return keys, nil
+}
+
+// Scan iterates over the keys matching a Redis-style glob pattern, a few at a
+// time, like the Redis SCAN command. Start with cursor 0 and pass the returned
+// cursor to the next call until it is 0 again.
+//
+// Every call visits whole shards until at least count keys were found, so a
+// call may return more or fewer keys than count, or none at all before the
+// iteration is over. A key stored during the whole iteration is returned
+// exactly once; keys written or removed meanwhile may or may not be returned.
+//
+// Parameters:
+//   - cursor: 0 to start an iteration, or the cursor returned by the previous call
+//   - pattern: The glob pattern the keys must match, empty to match every key
+//   - count: The number of keys to aim for (DefaultScanCount if not positive)
+//
+// Returns:
+//   - []string: The matching keys of the visited shards
+//   - uint64: The cursor of the next call, 0 when the iteration is complete
+//   - error: ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	var cursor uint64
+//	for {
+//	    keys, next, err := cache.Scan(cursor, "session:*", 100)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(keys)
+//	    if cursor = next; cursor == 0 {
+//	        break
+//	    }
+//	}
+func (c Cache) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return nil, 0, ErrClosed
+	}
+
+	if count <= 0 {
+		count = DefaultScanCount
+	}
+
+	start := time.Now()
+
+	var keys []string
+	for cursor < uint64(len(c)) && len(keys) < count {
+		group := c[cursor]
+		keys = group.keys(pattern, group.opt.clock.Now().UnixNano(), keys)
+		cursor++
+	}
+	if cursor >= uint64(len(c)) {
+		cursor = 0
+	}
+
+	if len(c) > 0 {
+		c[0].stats.Record(stats.OpScan, time.Since(start), stats.None)
+	}
+
+	return keys, cursor, nil
+}
+
+// Range calls fn for every item in the cache, in no particular order, until fn
+// returns false. Expired items are skipped.
+//
+// The items of a shard are copied under its read lock and fn runs once the lock
+// is released, so fn may use the cache. A value may therefore have been
+// replaced or removed by the time fn sees it. Nothing is visited after Close.
+// With CopyOnRead or SerializeValues fn receives copies; values that cannot be
+// copied are logged and skipped.
+//
+// Parameters:
+//   - fn: The function called with every key and value, returning false to stop
+//
+// Example:
+//
+//	cache.Range(func(key string, value any) bool {
+//	    fmt.Println(key, value)
+//	    return true
+//	})
+func (c Cache) Range(fn func(key string, value any) bool) {
+	var entries []Entry
+	for _, group := range c {
+		if group.opt.closed.Load() {
+			return
+		}
+
+		entries = group.entries(group.opt.clock.Now().UnixNano(), entries[:0])
+		for _, e := range entries {
+			value, err := group.copyOut(e.Value)
+			if err != nil {
+				group.opt.log.Error("cache range skipped a value", err,
+					slog.String("store", "mem"),
+					slog.String("key", e.Key),
+				)
+				continue
+			}
+			if !fn(e.Key, value) {
+				return
+			}
+		}
+	}
+}
+
+// keys appends the keys of the shard matching pattern to dst, skipping the
+// items expired at now (Unix nano).
+func (g *cache) keys(pattern string, now int64, dst []string) []string {
+	g.RLock()
+	defer g.RUnlock()
+
+	g.each(glob.Prefix(pattern), func(key string, it *item) {
+		if it.Expiration > 0 && now > it.Expiration {
+			return
+		}
+		if pattern == "" || glob.Match(pattern, key) {
+			dst = append(dst, key)
+		}
+	})
+
+	return dst
+}
+
+// each calls fn with every item of the shard whose key starts with prefix,
+// using the prefix index when enabled. fn must not add or remove items.
+// The caller must hold the lock.
+func (g *cache) each(prefix string, fn func(key string, it *item)) {
+	if g.index != nil {
+		g.index.walkPrefix(prefix, func(key string) {
+			fn(key, g.items[key])
+		})
+		return
+	}
+
+	for key, it := range g.items {
+		if strings.HasPrefix(key, prefix) {
+			fn(key, it)
+		}
+	}
+}
+
+// entries appends the items of the shard to dst, skipping the items expired
+// at now (Unix nano).
+func (g *cache) entries(now int64, dst []Entry) []Entry {
+	g.RLock()
+	defer g.RUnlock()
+
+	for key, it := range g.items {
+		if it.Expiration > 0 && now > it.Expiration {
+			continue
+		}
+		dst = append(dst, Entry{Key: key, Value: it.value, Expiration: it.Expiration, Idle: it.slide.idle, Deadline: it.slide.deadline})
+	}
+
+	return dst
+}
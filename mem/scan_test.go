@@ -0,0 +1,149 @@
+package mem
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+)
+
+func TestKeys(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1000, 0))
+	c := Init(WithClock(clk), WithJanitorInterval(0))
+	defer c.Close()
+
+	for _, key := range []string{"user:1", "user:2", "user:10", "session:1", "user*"} {
+		_ = c.Put(key, key, 0)
+	}
+	_ = c.Put("user:3", 3, 1)
+	clk.Advance(2 * time.Second)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", []string{"session:1", "user*", "user:1", "user:10", "user:2"}},
+		{"user:*", []string{"user:1", "user:10", "user:2"}},
+		{"user:?", []string{"user:1", "user:2"}},
+		{"user:[^2]*", []string{"user:1", "user:10"}},
+		{`user\*`, []string{"user*"}},
+		{"nothing:*", nil},
+	}
+	for _, tt := range tests {
+		keys, err := c.Keys(tt.pattern)
+		if err != nil {
+			t.Fatalf("Keys(%q) failed: %v", tt.pattern, err)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, tt.want) {
+			t.Errorf("Keys(%q) = %v, want %v", tt.pattern, keys, tt.want)
+		}
+	}
+
+	// Enumerating keys leaves expired items to the janitor and lookups
+	if n := c.Stats().Expirations; n != 0 {
+		t.Error("Expected Keys not to remove expired items, got expirations:", n)
+	}
+}
+
+func TestScan(t *testing.T) {
+	c := Init(WithShards(8))
+	defer c.Close()
+
+	want := make([]string, 0, 100)
+	for i := range 100 {
+		key := "user:" + strconv.Itoa(i)
+		want = append(want, key)
+		_ = c.Put(key, i, 0)
+		_ = c.Put("session:"+strconv.Itoa(i), i, 0)
+	}
+	slices.Sort(want)
+
+	var keys []string
+	var cursor uint64
+	calls := 0
+	for {
+		found, next, err := c.Scan(cursor, "user:*", 10)
+		if err != nil {
+			t.Fatal("Scan failed:", err)
+		}
+		keys = append(keys, found...)
+		calls++
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	slices.Sort(keys)
+	if !slices.Equal(keys, want) {
+		t.Errorf("Expected every key exactly once, got %d keys: %v", len(keys), keys)
+	}
+	if calls < 2 || calls > 8 {
+		t.Error("Expected the iteration to take between 2 and 8 calls, took:", calls)
+	}
+
+	// A count larger than the cache completes in one call
+	found, next, err := c.Scan(0, "", 1000)
+	if err != nil || next != 0 || len(found) != 200 {
+		t.Errorf("Expected 200 keys in a single call, got %d keys, cursor %d, error %v", len(found), next, err)
+	}
+
+	// A cursor past the last shard ends the iteration
+	if found, next, err := c.Scan(100, "", 10); err != nil || next != 0 || len(found) != 0 {
+		t.Errorf("Expected an invalid cursor to end the iteration, got %v, %d, %v", found, next, err)
+	}
+}
+
+func TestRange(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1000, 0))
+	c := Init(WithClock(clk), WithJanitorInterval(0))
+	defer c.Close()
+
+	for i := range 50 {
+		_ = c.Put(strconv.Itoa(i), i, 0)
+	}
+	_ = c.Put("expired", -1, 1)
+	clk.Advance(2 * time.Second)
+
+	// fn runs without the shard lock held, so it may write to the cache
+	sum := 0
+	c.Range(func(key string, value any) bool {
+		n := value.(int)
+		if n < 0 {
+			t.Error("Expected Range to skip expired items, got:", key)
+		}
+		sum += n
+		_ = c.Put(key, n+100, 0)
+		return true
+	})
+	if sum != 49*50/2 {
+		t.Error("Expected Range to visit every item once, sum:", sum)
+	}
+	if v, _ := c.Get("7"); v != 107 {
+		t.Error("Expected the writes of fn to be stored, got:", v)
+	}
+
+	visited := 0
+	c.Range(func(string, any) bool {
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Error("Expected Range to stop when fn returns false, visited:", visited)
+	}
+
+	_ = c.Close()
+	if _, err := c.Keys(""); !errors.Is(err, ErrClosed) {
+		t.Error("Expected Keys to return ErrClosed, got:", err)
+	}
+	if _, _, err := c.Scan(0, "", 0); !errors.Is(err, ErrClosed) {
+		t.Error("Expected Scan to return ErrClosed, got:", err)
+	}
+	c.Range(func(string, any) bool {
+		t.Error("Expected Range to visit nothing after Close")
+		return false
+	})
+}
@@ -0,0 +1,216 @@
+package mem
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+)
+
+// ErrTooLarge is returned when a value alone exceeds the byte budget set with WithMaxBytes.
+var ErrTooLarge = errors.New("mem: value exceeds the byte budget")
+
+// Sizer estimates the memory used by an item, in bytes.
+// See WithSizer for details.
+type Sizer func(key string, value any) int64
+
+// DefaultSizer estimates the size of an item as the length of its key plus
+// EstimateSize of its value.
+//
+// Parameters:
+//   - key: The key of the item
+//   - value: The value of the item
+//
+// Returns:
+//   - int64: The estimated size in bytes
+func DefaultSizer(key string, value any) int64 {
+	return int64(len(key)) + EstimateSize(value)
+}
+
+// EstimateSize estimates the memory used by v, in bytes.
+// Strings and byte slices are measured by their length; anything else is walked
+// with reflection, adding up the size of the value and of everything it points
+// to. Shared pointers are counted once, channels and functions count as a pointer.
+//
+// Parameters:
+//   - v: The value to measure
+//
+// Returns:
+//   - int64: The estimated size in bytes
+//
+// Example:
+//
+//	n := mem.EstimateSize(map[string][]int{"a": {1, 2, 3}})
+func EstimateSize(v any) int64 {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+
+	rv := reflect.ValueOf(v)
+	return int64(rv.Type().Size()) + indirectSize(rv, make(map[uintptr]struct{}))
+}
+
+// indirectSize returns the size of the memory referenced by v but not stored inline.
+func indirectSize(v reflect.Value, seen map[uintptr]struct{}) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Len())
+	case reflect.Pointer:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + indirectSize(e, seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		e := v.Elem()
+		return int64(e.Type().Size()) + indirectSize(e, seen)
+	case reflect.Slice:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		if hasIndirect(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				n += indirectSize(v.Index(i), seen)
+			}
+		}
+		return n
+	case reflect.Array:
+		var n int64
+		if hasIndirect(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				n += indirectSize(v.Index(i), seen)
+			}
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += indirectSize(v.Field(i), seen)
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() || visited(v.Pointer(), seen) {
+			return 0
+		}
+		t := v.Type()
+		n := int64(v.Len()) * int64(t.Key().Size()+t.Elem().Size())
+		if hasIndirect(t.Key()) || hasIndirect(t.Elem()) {
+			iter := v.MapRange()
+			for iter.Next() {
+				n += indirectSize(iter.Key(), seen) + indirectSize(iter.Value(), seen)
+			}
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+// hasIndirect reports whether values of type t may reference memory outside of themselves.
+func hasIndirect(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	case reflect.Array:
+		return hasIndirect(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if hasIndirect(t.Field(i).Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// visited reports whether p was already counted, and marks it as counted.
+func visited(p uintptr, seen map[uintptr]struct{}) bool {
+	if _, ok := seen[p]; ok {
+		return true
+	}
+	seen[p] = struct{}{}
+	return false
+}
+
+// budget tracks the estimated bytes used by all shards of a cache.
+// A nil *budget means the cache has no byte limit.
+type budget struct {
+	max   int64         // Maximum number of bytes
+	used  atomic.Int64  // Estimated bytes currently stored
+	next  atomic.Uint32 // Shard where the next reclaim starts
+	sizer Sizer         // Estimates the size of items
+}
+
+// add adjusts the number of used bytes by delta.
+func (b *budget) add(delta int64) {
+	if b != nil && delta != 0 {
+		b.used.Add(delta)
+	}
+}
+
+// over reports whether more bytes are used than allowed.
+func (b *budget) over() bool {
+	return b != nil && b.used.Load() > b.max
+}
+
+// fits reports whether an item of the given size can be stored at all.
+func (b *budget) fits(size int64) bool {
+	return b == nil || size <= b.max
+}
+
+// sizeOf returns the estimated size of an item, or 0 when the shard has no byte limit.
+func (g *cache) sizeOf(key string, value any) int64 {
+	if g.budget == nil {
+		return 0
+	}
+	return g.budget.sizer(key, value)
+}
+
+// reclaim evicts the least recently used items of the other shards, in turn,
+// while the byte budget is exceeded. The shard storing an item (from) already
+// evicted everything but that item; reclaim handles what remains, e.g. after a
+// large value was stored in a shard with nothing left to evict.
+// It must be called without holding any lock.
+func (c Cache) reclaim(from *cache) {
+	if len(c) == 0 {
+		return
+	}
+
+	b := c[0].budget
+	if !b.over() {
+		return
+	}
+
+	start := int(b.next.Add(1))
+	for i := range c {
+		group := c[(start+i)%len(c)]
+		if group == from {
+			continue
+		}
+
+		var evicted []*item
+		group.Lock()
+		for b.over() {
+			victim := group.victim()
+			if victim == nil {
+				break
+			}
+			group.remove(victim)
+			evicted = append(evicted, victim)
+		}
+		group.Unlock()
+
+		group.evict(evicted)
+		if !b.over() {
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package mem
+
+import (
+	"time"
+
+	"github.com/sk-pkg/cache/stats"
+)
+
+// slide is the idle expiration of an item stored with PutSliding.
+type slide struct {
+	idle     int64 // Nanoseconds every Get extends the expiration by (0 = fixed expiration)
+	deadline int64 // Unix nano timestamp the expiration is never extended past (0 = no limit)
+}
+
+// PutSliding stores a value that expires once it has not been read for idle,
+// e.g. a session expiring after 30 minutes of inactivity. Every Get that finds
+// the value extends its expiration to idle from now; Has, Keys, Range and the
+// other reads do not. Storing the key again with Put ends the sliding expiration.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - idle: How long the value lives without being read (0 for no expiration)
+//
+// Returns:
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	cache.PutSliding("session:abc", session, 30*time.Minute)
+func (c Cache) PutSliding(key string, value any, idle time.Duration) error {
+	return c.PutSlidingWithLifetime(key, value, idle, 0)
+}
+
+// PutSlidingWithLifetime stores a value like PutSliding, but never extends its
+// expiration past lifetime from now, however often it is read.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store
+//   - idle: How long the value lives without being read (0 for no expiration)
+//   - lifetime: The maximum time the value lives (0 for no limit)
+//
+// Returns:
+//   - error: ErrTooLarge if the value exceeds the byte budget, ErrUncopyable if it
+//     cannot be copied for WithCopyMode, ErrClosed after Close, nil otherwise
+//
+// Example:
+//
+//	// Log out after 30 minutes of inactivity, and after 12 hours in any case
+//	cache.PutSlidingWithLifetime("session:abc", session, 30*time.Minute, 12*time.Hour)
+func (c Cache) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	group := c.getGroup(key)
+	if group.opt.closed.Load() {
+		return ErrClosed
+	}
+
+	value, err := group.copyIn(value)
+	if err != nil {
+		group.record(key, stats.OpPut, time.Time{}, stats.Error)
+		return err
+	}
+
+	now := group.opt.clock.Now().UnixNano()
+	s := slide{idle: max(int64(idle), 0)}
+	if lifetime > 0 {
+		s.deadline = now + int64(lifetime)
+	}
+
+	return c.set(group, stats.OpPut, key, value, s.expiration(now), group.sizeOf(key, value), s)
+}
+
+// expiration returns the expiration of an item read at now (Unix nano),
+// 0 if it does not expire.
+func (s slide) expiration(now int64) int64 {
+	var exp int64
+	if s.idle > 0 {
+		exp = now + s.idle
+	}
+	if s.deadline > 0 && (exp == 0 || s.deadline < exp) {
+		exp = s.deadline
+	}
+	return exp
+}
+
+// extend moves the expiration of a sliding item to idle from now, and leaves
+// the other items alone. The caller must hold the write lock.
+func (g *cache) extend(it *item) {
+	if it.slide.idle <= 0 {
+		return
+	}
+
+	if exp := it.slide.expiration(g.opt.clock.Now().UnixNano()); exp != it.Expiration {
+		it.Expiration = exp
+		g.schedule(it)
+	}
+}
+
+// refresh extends a sliding item read under the read lock, unless it was
+// written again or expired in the meantime.
+// It must be called without holding the lock.
+func (g *cache) refresh(it *item) {
+	g.Lock()
+	if g.items[it.key] == it && !g.expired(it) {
+		g.extend(it)
+	}
+	g.Unlock()
+}
@@ -0,0 +1,97 @@
+package mem
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+)
+
+func TestPutSliding(t *testing.T) {
+	for _, bounded := range []bool{false, true} {
+		clk := clocktest.NewFake(time.Unix(1000, 0))
+		opts := []Option{WithClock(clk), WithJanitorInterval(0)}
+		if bounded {
+			opts = append(opts, WithMaxEntries(100))
+		}
+		c := Init(opts...)
+
+		_ = c.PutSliding("session", "token", 10*time.Second)
+
+		// Every Get extends the expiration
+		for range 5 {
+			clk.Advance(8 * time.Second)
+			if v, _ := c.Get("session"); v != "token" {
+				t.Fatalf("bounded=%v: expected the session to be extended, got %v", bounded, v)
+			}
+		}
+		if n := c.Len(); n != 1 {
+			t.Errorf("bounded=%v: expected the extended session to be live, got %d items", bounded, n)
+		}
+
+		// Has does not extend it
+		clk.Advance(8 * time.Second)
+		if !c.Has("session") {
+			t.Errorf("bounded=%v: expected the session to be live", bounded)
+		}
+		clk.Advance(3 * time.Second)
+		if v, _ := c.Get("session"); v != nil {
+			t.Errorf("bounded=%v: expected the idle session to expire, got %v", bounded, v)
+		}
+
+		// Put ends the sliding expiration
+		_ = c.PutSliding("key", 1, 10*time.Second)
+		_ = c.Put("key", 2, 5)
+		clk.Advance(4 * time.Second)
+		_, _ = c.Get("key")
+		clk.Advance(2 * time.Second)
+		if v, _ := c.Get("key"); v != nil {
+			t.Errorf("bounded=%v: expected Put to end the sliding expiration, got %v", bounded, v)
+		}
+
+		_ = c.Close()
+	}
+}
+
+func TestPutSlidingWithLifetime(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1000, 0))
+	c := Init(WithClock(clk), WithJanitorInterval(0))
+	defer c.Close()
+
+	_ = c.PutSlidingWithLifetime("session", "token", 10*time.Second, 25*time.Second)
+	_ = c.PutSlidingWithLifetime("no idle", "token", 0, 5*time.Second)
+
+	// Reads extend the expiration up to the lifetime only
+	for range 3 {
+		clk.Advance(8 * time.Second)
+		if v, _ := c.Get("session"); v != "token" {
+			t.Fatal("Expected the session to be extended, got:", v)
+		}
+	}
+	if c.Has("no idle") {
+		t.Error("Expected the value without idle timeout to expire after its lifetime")
+	}
+	clk.Advance(time.Second + time.Millisecond)
+	if v, _ := c.Get("session"); v != nil {
+		t.Error("Expected the session to expire after its lifetime, got:", v)
+	}
+
+	// Snapshots keep the sliding expiration
+	_ = c.PutSlidingWithLifetime("session", "token", 10*time.Second, time.Minute)
+	var buf bytes.Buffer
+	if err := c.SaveTo(&buf); err != nil {
+		t.Fatal("SaveTo failed:", err)
+	}
+	dst := Init(WithClock(clk), WithJanitorInterval(0))
+	defer dst.Close()
+	if err := dst.LoadFrom(&buf); err != nil {
+		t.Fatal("LoadFrom failed:", err)
+	}
+	clk.Advance(8 * time.Second)
+	_, _ = dst.Get("session")
+	clk.Advance(8 * time.Second)
+	if v, _ := dst.Get("session"); v != "token" {
+		t.Error("Expected the restored session to be extended, got:", v)
+	}
+}
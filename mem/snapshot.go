@@ -0,0 +1,423 @@
+package mem
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sk-pkg/cache/clock"
+	"github.com/sk-pkg/cache/stats"
+)
+
+// snapshotMagic starts every snapshot, followed by the format version.
+const snapshotMagic = "SKMEMSNP"
+
+// snapshotVersion is the version of the snapshot format written by SaveTo.
+const snapshotVersion byte = 1
+
+// ErrSnapshotFormat is returned by LoadFrom when the data is not a snapshot,
+// or was written in a format version this package cannot read.
+var ErrSnapshotFormat = errors.New("mem: unsupported snapshot format")
+
+// Entry is an item of a snapshot, as encoded by the Codec.
+type Entry struct {
+	Key        string // The key of the item
+	Value      any    // The stored value
+	Expiration int64  // Unix nano timestamp when the item expires (0 = no expiration)
+	Idle       int64  // Nanoseconds a Get extends the expiration by (0 = fixed expiration)
+	Deadline   int64  // Unix nano timestamp the expiration is never extended past (0 = no limit)
+}
+
+// Encoder writes the values of a snapshot. *gob.Encoder and *json.Encoder implement it.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads the values of a snapshot. *gob.Decoder and *json.Decoder implement it.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec encodes the entries of a snapshot. See WithSnapshotCodec.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// GobCodec is the default Codec of snapshots. Values are stored in Entry.Value
+// as interfaces, so their concrete types must be registered with gob.Register,
+// except for the built-in types.
+type GobCodec struct{}
+
+// NewEncoder returns a gob encoder writing to w.
+func (GobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+// NewDecoder returns a gob decoder reading from r.
+func (GobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+// WithSnapshotCodec returns an Option that sets the Codec used by SaveTo and
+// LoadFrom (default GobCodec). A snapshot must be loaded with the codec it was
+// saved with.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithSnapshotCodec(msgpackCodec{}))
+func WithSnapshotCodec(c Codec) Option {
+	return func(o *option) {
+		o.codec = c
+	}
+}
+
+// WithSnapshot returns an Option that keeps a snapshot of the cache in the file
+// at path, so that a restarted process does not start with a cold cache.
+// Init loads the file if it exists, the cache saves it every interval and once
+// more on Close. Files are written to a temporary file first and renamed, so a
+// crash never leaves a partial snapshot behind. A non-positive interval only
+// loads the file on Init and saves it on Close. Failures are logged.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithSnapshot("/var/lib/app/cache.snap", time.Minute))
+//	defer cache.Close() // Saves a last snapshot
+func WithSnapshot(path string, interval time.Duration) Option {
+	return func(o *option) {
+		o.snapshotPath = path
+		o.snapshotInterval = max(interval, 0)
+	}
+}
+
+// SaveTo writes every item of the cache that has not expired, with its
+// expiration time, to w. Each shard is copied under its read lock in turn, so
+// the snapshot is consistent per shard but not across shards.
+//
+// Parameters:
+//   - w: The writer receiving the snapshot
+//
+// Returns:
+//   - error: ErrClosed after Close, or any error encountered while encoding or writing
+//
+// Example:
+//
+//	var buf bytes.Buffer
+//	err := cache.SaveTo(&buf)
+func (c Cache) SaveTo(w io.Writer) error {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return ErrClosed
+	}
+
+	_, err := c.save(w)
+	return err
+}
+
+// LoadFrom reads a snapshot written by SaveTo and stores its items, keeping
+// their expiration times. Items that have expired since are skipped, and so
+// are items exceeding the byte budget. Loaded items replace the items stored
+// under the same keys and count as writes in the statistics.
+//
+// Parameters:
+//   - r: The reader providing the snapshot
+//
+// Returns:
+//   - error: ErrSnapshotFormat if r does not hold a snapshot, ErrClosed after
+//     Close, or any error encountered while reading or decoding
+//
+// Example:
+//
+//	f, _ := os.Open("cache.snap")
+//	defer f.Close()
+//	err := cache.LoadFrom(bufio.NewReader(f))
+func (c Cache) LoadFrom(r io.Reader) error {
+	_, err := c.load(r)
+	return err
+}
+
+// SaveFile writes a snapshot of the cache to the file at path, like SaveTo.
+// The snapshot is written to a temporary file in the same directory, which
+// then replaces the file at path.
+//
+// Parameters:
+//   - path: The path of the snapshot file
+//
+// Returns:
+//   - error: ErrClosed after Close, or any error encountered while writing the file
+//
+// Example:
+//
+//	err := cache.SaveFile("/var/lib/app/cache.snap")
+func (c Cache) SaveFile(path string) error {
+	if len(c) > 0 && c[0].opt.closed.Load() {
+		return ErrClosed
+	}
+
+	_, err := c.saveFile(path)
+	return err
+}
+
+// LoadFile reads a snapshot from the file at path, like LoadFrom.
+//
+// Parameters:
+//   - path: The path of the snapshot file
+//
+// Returns:
+//   - error: An error wrapping fs.ErrNotExist if there is no file, or any
+//     error returned by LoadFrom
+//
+// Example:
+//
+//	if err := cache.LoadFile("/var/lib/app/cache.snap"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+//	    log.Println("cache snapshot:", err)
+//	}
+func (c Cache) LoadFile(path string) error {
+	_, err := c.loadFile(path)
+	return err
+}
+
+// save writes a snapshot to w and returns the number of items written.
+func (c Cache) save(w io.Writer) (int, error) {
+	if len(c) == 0 {
+		return 0, nil
+	}
+
+	opt := c[0].opt
+	if _, err := w.Write(append([]byte(snapshotMagic), snapshotVersion)); err != nil {
+		return 0, err
+	}
+
+	// Each shard is written as its number of entries followed by the entries,
+	// and the snapshot ends with an empty shard
+	enc := opt.codec.NewEncoder(w)
+	now := opt.clock.Now().UnixNano()
+	saved := 0
+	for _, group := range c {
+		entries := group.entries(now, nil)
+
+		if len(entries) == 0 {
+			continue
+		}
+		if err := enc.Encode(len(entries)); err != nil {
+			return saved, fmt.Errorf("mem: encode snapshot: %w", err)
+		}
+		for i := range entries {
+			if err := enc.Encode(&entries[i]); err != nil {
+				return saved, fmt.Errorf("mem: encode snapshot item %q: %w", entries[i].Key, err)
+			}
+		}
+		saved += len(entries)
+	}
+
+	if err := enc.Encode(0); err != nil {
+		return saved, fmt.Errorf("mem: encode snapshot: %w", err)
+	}
+
+	return saved, nil
+}
+
+// load reads a snapshot from r and returns the number of items stored.
+func (c Cache) load(r io.Reader) (int, error) {
+	if len(c) == 0 {
+		return 0, nil
+	}
+
+	opt := c[0].opt
+	if opt.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, ErrSnapshotFormat
+		}
+		return 0, err
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, ErrSnapshotFormat
+	}
+	if v := header[len(snapshotMagic)]; v != snapshotVersion {
+		return 0, fmt.Errorf("%w: version %d", ErrSnapshotFormat, v)
+	}
+
+	dec := opt.codec.NewDecoder(r)
+	now := opt.clock.Now().UnixNano()
+	loaded := 0
+	for {
+		var n int
+		if err := dec.Decode(&n); err != nil {
+			return loaded, fmt.Errorf("mem: decode snapshot: %w", err)
+		}
+		if n < 0 {
+			return loaded, fmt.Errorf("%w: negative item count", ErrSnapshotFormat)
+		}
+		if n == 0 {
+			return loaded, nil
+		}
+
+		for ; n > 0; n-- {
+			var e Entry
+			if err := dec.Decode(&e); err != nil {
+				return loaded, fmt.Errorf("mem: decode snapshot: %w", err)
+			}
+			if e.Expiration > 0 && now > e.Expiration {
+				continue
+			}
+
+			group := c.getGroup(e.Key)
+			err := c.set(group, stats.OpPut, e.Key, e.Value, e.Expiration, group.sizeOf(e.Key, e.Value), slide{idle: e.Idle, deadline: e.Deadline})
+			if errors.Is(err, ErrTooLarge) {
+				continue
+			}
+			if err != nil {
+				return loaded, err
+			}
+			loaded++
+		}
+	}
+}
+
+// saveFile writes a snapshot to a temporary file next to path, then renames
+// it to path. It returns the number of items written.
+func (c Cache) saveFile(path string) (n int, err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	if n, err = c.save(w); err != nil {
+		return n, err
+	}
+	if err = w.Flush(); err != nil {
+		return n, err
+	}
+	if err = f.Sync(); err != nil {
+		return n, err
+	}
+	if err = f.Close(); err != nil {
+		return n, err
+	}
+
+	return n, os.Rename(f.Name(), path)
+}
+
+// loadFile reads a snapshot from the file at path and returns the number of items stored.
+func (c Cache) loadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return c.load(bufio.NewReader(f))
+}
+
+// snapshotter periodically saves a snapshot of the cache to a file.
+type snapshotter struct {
+	path   string
+	ticker clock.Ticker
+	stop   chan struct{} // Closed to signal the snapshotter to stop
+	done   chan struct{} // Closed once the snapshotter has stopped
+}
+
+// restoreSnapshot loads the snapshot file configured with WithSnapshot, if
+// any, and starts saving it periodically.
+func (c Cache) restoreSnapshot() {
+	opt := c[0].opt
+	if opt.snapshotPath == "" {
+		return
+	}
+
+	start := time.Now()
+	n, err := c.loadFile(opt.snapshotPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Nothing was saved yet
+	case err != nil:
+		opt.log.Error("cache snapshot load failed", err,
+			slog.String("store", "mem"),
+			slog.String("path", opt.snapshotPath),
+		)
+	default:
+		opt.log.Sweep("cache snapshot loaded",
+			slog.String("store", "mem"),
+			slog.String("path", opt.snapshotPath),
+			slog.Int("items", n),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+
+	if opt.snapshotInterval <= 0 {
+		return
+	}
+
+	s := &snapshotter{
+		path:   opt.snapshotPath,
+		ticker: opt.clock.NewTicker(opt.snapshotInterval),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	opt.snapshots = s
+	go s.run(c)
+}
+
+// run saves a snapshot of c at every tick until the snapshotter is stopped.
+func (s *snapshotter) run(c Cache) {
+	defer close(s.done)
+
+	for {
+		select {
+		case <-s.ticker.C():
+			c.snapshot(s.path)
+		case <-s.stop:
+			s.ticker.Stop()
+			return
+		}
+	}
+}
+
+// stopSnapshots stops the periodic snapshots, if started, and waits until
+// a snapshot in progress is written.
+func (c Cache) stopSnapshots() {
+	if s := c[0].opt.snapshots; s != nil {
+		close(s.stop)
+		<-s.done
+	}
+}
+
+// snapshot saves a snapshot to path and logs the outcome.
+func (c Cache) snapshot(path string) error {
+	opt := c[0].opt
+	start := time.Now()
+	n, err := c.saveFile(path)
+	if err != nil {
+		opt.log.Error("cache snapshot failed", err,
+			slog.String("store", "mem"),
+			slog.String("path", path),
+		)
+		return err
+	}
+
+	opt.log.Sweep("cache snapshot saved",
+		slog.String("store", "mem"),
+		slog.String("path", path),
+		slog.Int("items", n),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
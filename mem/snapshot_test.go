@@ -0,0 +1,165 @@
+package mem
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+)
+
+type snapshotUser struct {
+	Name string
+	Age  int
+}
+
+func init() {
+	gob.Register(snapshotUser{})
+}
+
+func TestSnapshot(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1_000_000, 0))
+	src := Init(WithClock(clk), WithJanitorInterval(0))
+	_ = src.Forever("forever", "value")
+	_ = src.Put("user", snapshotUser{Name: "ada", Age: 36}, 60)
+	_ = src.Put("expired", 1, 1)
+	_, _ = src.Increment("counter", 3)
+	clk.Advance(2 * time.Second)
+
+	var buf bytes.Buffer
+	if err := src.SaveTo(&buf); err != nil {
+		t.Fatal("SaveTo failed:", err)
+	}
+
+	dst := Init(WithClock(clk), WithJanitorInterval(0))
+	if err := dst.LoadFrom(&buf); err != nil {
+		t.Fatal("LoadFrom failed:", err)
+	}
+	if v, _ := dst.Get("forever"); v != "value" {
+		t.Error("Expected the string to be restored, got:", v)
+	}
+	if v, _ := dst.Get("user"); v != (snapshotUser{Name: "ada", Age: 36}) {
+		t.Error("Expected the registered struct to be restored, got:", v)
+	}
+	if n, _ := dst.Increment("counter", 1); n != 4 {
+		t.Error("Expected the counter to be restored, got:", n)
+	}
+	if dst.Has("expired") {
+		t.Error("Expired items should not be saved")
+	}
+
+	// Items keep their expiration time
+	clk.Advance(57 * time.Second)
+	if !dst.Has("user") {
+		t.Error("The restored item expired early")
+	}
+	clk.Advance(2 * time.Second)
+	if dst.Has("user") {
+		t.Error("The restored item should expire with its original TTL")
+	}
+
+	// Items that expired since the snapshot was taken are skipped
+	buf.Reset()
+	_ = src.SaveTo(&buf)
+	late := Init(WithClock(clk), WithJanitorInterval(0))
+	if err := late.LoadFrom(&buf); err != nil {
+		t.Fatal("LoadFrom failed:", err)
+	}
+	if late.Has("user") || !late.Has("forever") {
+		t.Error("Expected only the items that are still alive to be loaded")
+	}
+}
+
+func TestSnapshotFormat(t *testing.T) {
+	c := Init()
+	for name, data := range map[string][]byte{
+		"empty":   nil,
+		"garbage": []byte("definitely not a snapshot"),
+		"version": append([]byte(snapshotMagic), snapshotVersion+1),
+	} {
+		if err := c.LoadFrom(bytes.NewReader(data)); !errors.Is(err, ErrSnapshotFormat) {
+			t.Errorf("%s: expected ErrSnapshotFormat, got: %v", name, err)
+		}
+	}
+
+	_ = c.Close()
+	if err := c.SaveTo(io.Discard); !errors.Is(err, ErrClosed) {
+		t.Error("Expected SaveTo to return ErrClosed, got:", err)
+	}
+}
+
+// jsonCodec encodes snapshots as a stream of JSON values.
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+func TestSnapshotCodec(t *testing.T) {
+	src := Init(WithSnapshotCodec(jsonCodec{}))
+	_ = src.Put("a", "b", 60)
+
+	var buf bytes.Buffer
+	if err := src.SaveTo(&buf); err != nil {
+		t.Fatal("SaveTo failed:", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"Key":"a"`)) {
+		t.Error("Expected the entries to be encoded as JSON, got:", buf.String())
+	}
+
+	dst := Init(WithSnapshotCodec(jsonCodec{}))
+	if err := dst.LoadFrom(&buf); err != nil {
+		t.Fatal("LoadFrom failed:", err)
+	}
+	if v, _ := dst.Get("a"); v != "b" {
+		t.Error("Expected the item to be restored, got:", v)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.snap")
+	clk := clocktest.NewFake(time.Now())
+
+	c := Init(WithClock(clk), WithSnapshot(path, time.Minute))
+	_ = c.Put("a", 1, 0)
+
+	// A tick saves the file in the background
+	clk.Advance(time.Minute)
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a snapshot to be saved at the interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Close saves the last changes
+	_ = c.Put("b", 2, 0)
+	if err := c.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	restored := Init(WithClock(clk), WithSnapshot(path, 0))
+	if !restored.Has("a") || !restored.Has("b") {
+		t.Error("Expected Init to load the snapshot file")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Error("Expected only the snapshot file to be left, got:", entries)
+	}
+
+	// Without a file, the cache starts empty
+	if err := Init().LoadFile(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("Expected a missing file to be reported, got:", err)
+	}
+}
@@ -0,0 +1,310 @@
+package mem
+
+import "hash/maphash"
+
+// Regions of the W-TinyLFU policy an item can be in.
+const (
+	regionWindow    uint8 = iota // Admission window, where new items start
+	regionProbation              // Main region, items accessed once since they left the window
+	regionProtected              // Main region, items accessed again while on probation
+)
+
+const (
+	// sketchDepth is the number of counter rows of the count-min sketch
+	sketchDepth = 4
+	// sketchMinWidth is the smallest number of counters per row
+	sketchMinWidth = 64
+	// sketchMaxCount is the value counters saturate at (4-bit counters)
+	sketchMaxCount = 15
+)
+
+// tinyLFU is a W-TinyLFU policy. New items enter an LRU window holding 1% of
+// the shard. The item most recently pushed out of the window is the admission
+// candidate: on the next eviction it competes with the oldest item on
+// probation, and the one accessed less often, as estimated by a count-min
+// sketch, is evicted. The main region is a segmented LRU: items
+// accessed again while on probation are promoted to the protected segment,
+// which holds up to 80% of the main region. This keeps one-off scans from
+// flushing frequently used items.
+type tinyLFU struct {
+	nodes     map[string]*node
+	window    list   // New items, most recent first
+	probation list   // Main region items on probation, most recent first
+	protected list   // Main region items accessed repeatedly, most recent first
+	candidate *node  // Last item moved out of the window, nil once it competed or was removed
+	sketch    sketch // Access frequency estimator
+	capacity  int    // Maximum number of items (0 = sized after the current item count)
+}
+
+// NewTinyLFU returns a W-TinyLFU Policy, which resists one-off scans polluting
+// the cache by only admitting items used more often than the item they replace.
+// Its frequency sketch is sized after capacity, or grows with the shard when
+// capacity is 0.
+//
+// Example:
+//
+//	cache := mem.Init(mem.WithMaxEntries(10_000), mem.WithPolicy(mem.NewTinyLFU))
+func NewTinyLFU(capacity int) Policy {
+	p := &tinyLFU{nodes: make(map[string]*node, capacity), capacity: capacity}
+	p.window.init()
+	p.probation.init()
+	p.protected.init()
+	p.sketch.seed = maphash.MakeSeed()
+	p.sketch.init(max(capacity, sketchMinWidth))
+	return p
+}
+
+// len returns the number of items tracked by the policy.
+func (p *tinyLFU) len() int {
+	return p.window.len + p.probation.len + p.protected.len
+}
+
+// size returns the number of items the regions are sized after.
+func (p *tinyLFU) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return p.len()
+}
+
+// windowMax returns the maximum number of items in the window.
+func (p *tinyLFU) windowMax() int {
+	return max(1, p.size()/100)
+}
+
+// protectedMax returns the maximum number of items in the protected segment.
+func (p *tinyLFU) protectedMax() int {
+	return (p.size() - p.windowMax()) * 8 / 10
+}
+
+// list returns the list of the region n is in.
+func (p *tinyLFU) list(n *node) *list {
+	switch n.region {
+	case regionProbation:
+		return &p.probation
+	case regionProtected:
+		return &p.protected
+	default:
+		return &p.window
+	}
+}
+
+// OnInsert counts an access of key and adds it to the window.
+func (p *tinyLFU) OnInsert(key string) {
+	p.sketch.increment(key)
+	n := &node{key: key, region: regionWindow}
+	p.nodes[key] = n
+	p.window.pushFront(n)
+
+	// Move the oldest window items to the main region
+	for p.window.len > p.windowMax() {
+		moved := p.window.back()
+		p.window.remove(moved)
+		moved.region = regionProbation
+		p.probation.pushFront(moved)
+		p.candidate = moved
+	}
+
+	// Without an item limit, the sketch grows with the shard
+	if p.capacity == 0 && p.len() > p.sketch.width() {
+		p.sketch.grow()
+	}
+}
+
+// OnAccess counts an access of key and promotes it on probation to the protected segment.
+func (p *tinyLFU) OnAccess(key string) {
+	p.sketch.increment(key)
+
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	switch n.region {
+	case regionProbation:
+		// Promote to the protected segment, demoting its oldest item if it is full
+		p.probation.remove(n)
+		n.region = regionProtected
+		p.protected.pushFront(n)
+
+		if p.protected.len > p.protectedMax() {
+			demoted := p.protected.back()
+			p.protected.remove(demoted)
+			demoted.region = regionProbation
+			p.probation.pushFront(demoted)
+		}
+	default:
+		p.list(n).moveToFront(n)
+	}
+}
+
+// OnMiss counts an access of key, so that keys requested often are admitted once stored.
+func (p *tinyLFU) OnMiss(key string) {
+	p.sketch.increment(key)
+}
+
+// OnRemove forgets key.
+func (p *tinyLFU) OnRemove(key string) {
+	n, ok := p.nodes[key]
+	if !ok {
+		return
+	}
+
+	if n == p.candidate {
+		p.candidate = nil
+	}
+	p.list(n).remove(n)
+	delete(p.nodes, key)
+}
+
+// Victim returns the admission candidate if it is used less often than the
+// oldest item on probation, and that item otherwise.
+func (p *tinyLFU) Victim() (string, bool) {
+	victim := p.probation.back()
+	if victim == nil {
+		victim = p.protected.back()
+	}
+	if victim == nil {
+		victim = p.window.back()
+	}
+	if victim == nil {
+		return "", false
+	}
+
+	// Admit the candidate only if it is used more often than the victim
+	candidate := p.candidate
+	p.candidate = nil
+	if candidate != nil && candidate != victim && candidate.region == regionProbation &&
+		p.sketch.estimate(candidate.key) <= p.sketch.estimate(victim.key) {
+		return candidate.key, true
+	}
+	return victim.key, true
+}
+
+// sketch is a count-min sketch estimating how often keys were accessed.
+// A doorkeeper bloom filter absorbs the first access of every key, so that
+// keys seen only once do not take up counters. Once enough accesses were
+// counted, all counters are halved and the doorkeeper is cleared, so that the
+// estimates follow changes in popularity.
+type sketch struct {
+	counters  []uint8  // sketchDepth rows of width() counters
+	door      []uint64 // Doorkeeper bits, one per counter of a row
+	mask      uint64   // width() - 1
+	additions int      // Accesses counted since the last reset
+	resetAt   int      // Number of additions that triggers a reset
+	seed      maphash.Seed
+}
+
+// init allocates empty counters for at least n keys.
+func (s *sketch) init(n int) {
+	width := sketchMinWidth
+	for width < n {
+		width <<= 1
+	}
+
+	s.counters = make([]uint8, sketchDepth*width)
+	s.door = make([]uint64, width/64)
+	s.mask = uint64(width - 1)
+	s.additions = 0
+	s.resetAt = 10 * width
+}
+
+// grow doubles the number of counters per row, keeping every estimate. The
+// counter of a hash in a row of the wider sketch is either at its old position
+// or one old width further, so both start from the old count; the doorkeeper
+// bits are carried over the same way.
+func (s *sketch) grow() {
+	width := s.width()
+	counters := make([]uint8, 2*sketchDepth*width)
+	for i := range sketchDepth {
+		row := s.counters[i*width : (i+1)*width]
+		copy(counters[2*i*width:], row)
+		copy(counters[(2*i+1)*width:], row)
+	}
+
+	door := make([]uint64, 2*len(s.door))
+	copy(door, s.door)
+	copy(door[len(s.door):], s.door)
+
+	s.counters = counters
+	s.door = door
+	s.mask = uint64(2*width - 1)
+	s.resetAt = 20 * width
+}
+
+// width returns the number of counters per row.
+func (s *sketch) width() int {
+	return int(s.mask + 1)
+}
+
+// index returns the position of the counter of hash h in row i.
+func (s *sketch) index(h uint64, i int) int {
+	// Derive the row hashes from both halves of h (double hashing)
+	lo, hi := h, h>>32|h<<32
+	return i*s.width() + int((lo+uint64(i)*hi)&s.mask)
+}
+
+// increment counts an access of key.
+func (s *sketch) increment(key string) {
+	h := maphash.String(s.seed, key)
+
+	if s.admit(h) {
+		for i := range sketchDepth {
+			if c := &s.counters[s.index(h, i)]; *c < sketchMaxCount {
+				*c++
+			}
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.halve()
+	}
+}
+
+// estimate returns the estimated number of accesses of key.
+func (s *sketch) estimate(key string) int {
+	h := maphash.String(s.seed, key)
+
+	n := sketchMaxCount
+	for i := range sketchDepth {
+		n = min(n, int(s.counters[s.index(h, i)]))
+	}
+
+	if s.seen(h) {
+		n++
+	}
+	return n
+}
+
+// doorBits returns the two doorkeeper bit positions of hash h.
+func (s *sketch) doorBits(h uint64) (uint64, uint64) {
+	return h & s.mask, (h >> 32) & s.mask
+}
+
+// seen reports whether the doorkeeper holds hash h.
+func (s *sketch) seen(h uint64) bool {
+	a, b := s.doorBits(h)
+	return s.door[a/64]&(1<<(a%64)) != 0 && s.door[b/64]&(1<<(b%64)) != 0
+}
+
+// admit adds hash h to the doorkeeper and reports whether it was already there.
+func (s *sketch) admit(h uint64) bool {
+	if s.seen(h) {
+		return true
+	}
+
+	a, b := s.doorBits(h)
+	s.door[a/64] |= 1 << (a % 64)
+	s.door[b/64] |= 1 << (b % 64)
+	return false
+}
+
+// halve ages the sketch: counters are halved and the doorkeeper is cleared.
+func (s *sketch) halve() {
+	for i := range s.counters {
+		s.counters[i] >>= 1
+	}
+	clear(s.door)
+	s.additions /= 2
+}
@@ -0,0 +1,76 @@
+package mem
+
+import "github.com/sk-pkg/cache/stats"
+
+// usageSample is the number of items per shard whose size Usage estimates
+// when no byte limit is set.
+const usageSample = 32
+
+// Len returns the number of items stored and not expired. The shards are
+// counted one at a time, so the result is not a point-in-time view.
+//
+// Returns:
+//   - int: The number of live items
+//
+// Example:
+//
+//	fmt.Println("items:", cache.Len())
+func (c Cache) Len() int {
+	n := 0
+	for _, group := range c {
+		group.RLock()
+		n += len(group.items) - group.expiries.countDue(group.opt.clock.Now().UnixNano())
+		group.RUnlock()
+	}
+
+	return n
+}
+
+// Usage reports how much every shard holds: the live items, the expired items
+// the janitor has not removed yet, and the estimated bytes. With WithMaxBytes
+// the bytes are those accounted against the limit; otherwise they are
+// extrapolated from the sizes of a few items per shard, estimated with
+// DefaultSizer, and Sampled is set.
+//
+// Returns:
+//   - stats.Usage: The totals and the usage of every shard, indexed by shard number
+//
+// Example:
+//
+//	u := cache.Usage()
+//	fmt.Printf("%d items, %d expired, ~%d bytes\n", u.Items, u.Expired, u.Bytes)
+func (c Cache) Usage() stats.Usage {
+	var u stats.Usage
+	for _, group := range c {
+		s, sampled := group.usage()
+		u.Add(s)
+		u.Sampled = u.Sampled || sampled
+	}
+
+	return u
+}
+
+// usage returns the usage of the shard, and whether its bytes were
+// extrapolated from a sample.
+func (g *cache) usage() (stats.ShardUsage, bool) {
+	g.RLock()
+	defer g.RUnlock()
+
+	expired := int64(g.expiries.countDue(g.opt.clock.Now().UnixNano()))
+	u := stats.ShardUsage{Items: int64(len(g.items)) - expired, Expired: expired, Bytes: g.bytes}
+	if g.budget != nil || len(g.items) == 0 {
+		return u, false
+	}
+
+	// Map iteration starts at a random item, which makes the sample random enough
+	var size, n int64
+	for key, it := range g.items {
+		size += DefaultSizer(key, it.value)
+		if n++; n == usageSample {
+			break
+		}
+	}
+	u.Bytes = size * int64(len(g.items)) / n
+
+	return u, n < int64(len(g.items))
+}
@@ -0,0 +1,60 @@
+package mem
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sk-pkg/cache/clock/clocktest"
+)
+
+func TestUsage(t *testing.T) {
+	clk := clocktest.NewFake(time.Unix(1000, 0))
+	c := Init(WithClock(clk), WithJanitorInterval(0), WithShards(4))
+	defer c.Close()
+
+	for i := range 100 {
+		_ = c.Put("k"+strconv.Itoa(i), i, 0)
+	}
+	for i := range 30 {
+		_ = c.Put("t"+strconv.Itoa(i), i, i+1)
+	}
+	clk.Advance(10*time.Second + time.Millisecond)
+
+	// The items with a TTL of up to 10 seconds expired, and nothing removed them
+	if n := c.Len(); n != 120 {
+		t.Error("Expected 120 live items, got:", n)
+	}
+
+	u := c.Usage()
+	if u.Items != 120 || u.Expired != 10 || len(u.Shards) != 4 {
+		t.Errorf("Expected 120 items and 10 expired over 4 shards, got %+v", u)
+	}
+	var items, expired int64
+	for i, s := range u.Shards {
+		items += s.Items
+		expired += s.Expired
+		if n := c.ShardLens()[i]; int64(n) != s.Items+s.Expired {
+			t.Errorf("Shard %d: expected %d items in total, got %+v", i, n, s)
+		}
+	}
+	if items != u.Items || expired != u.Expired {
+		t.Error("Expected the totals to add up the shards, got:", u)
+	}
+
+	// Without a byte limit the bytes are estimated from a sample of every shard
+	if !u.Sampled || u.Bytes <= 0 {
+		t.Error("Expected sampled bytes, got:", u)
+	}
+	if s := c.Stats(); s.Usage.Items != 120 {
+		t.Error("Expected Stats to include the usage, got:", s.Usage)
+	}
+
+	// With a byte limit, the accounted bytes are exact
+	b := Init(WithMaxBytes(1<<20), WithShards(2))
+	defer b.Close()
+	_ = b.Put("a", "value", 0)
+	if u := b.Usage(); u.Sampled || u.Bytes != DefaultSizer("a", "value") {
+		t.Errorf("Expected %d accounted bytes, got %+v", DefaultSizer("a", "value"), u)
+	}
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sk-pkg/cache/stats"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler returns an http.Handler that exposes the cache statistics in
+// the Prometheus text exposition format, without depending on client_golang.
+//
+// The following metrics are exported, labelled by driver:
+//   - cache_hits_total, cache_misses_total and cache_hit_ratio
+//   - cache_writes_total, cache_deletes_total, cache_errors_total,
+//     cache_evictions_total and cache_expirations_total
+//   - cache_operations_total by operation
+//   - cache_operation_duration_seconds histograms by operation
+//   - cache_prefix_*_total counters by key prefix, when WithStatsPrefix is used
+//   - cache_mem_items by memory cache shard
+//   - cache_redis_pool_active_connections and cache_redis_pool_idle_connections
+//
+// Returns:
+//   - http.Handler: A handler serving the metrics on any path
+//
+// Example:
+//
+//	http.Handle("/metrics", c.MetricsHandler())
+func (m *Manager) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		if err := m.WriteMetrics(w); err != nil {
+			m.log.Error("writing cache metrics failed", err)
+		}
+	})
+}
+
+// WriteMetrics writes the cache statistics to w in the Prometheus text exposition format.
+// See MetricsHandler for the list of exported metrics.
+//
+// Parameters:
+//   - w: The writer to write the metrics to
+//
+// Returns:
+//   - error: Any error returned by w
+func (m *Manager) WriteMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	all := m.Stats()
+
+	drivers := make([]string, 0, len(all))
+	for driver := range all {
+		drivers = append(drivers, driver)
+	}
+	slices.Sort(drivers)
+
+	counters := []struct {
+		name, help string
+		value      func(s stats.Snapshot) uint64
+	}{
+		{"hits", "Lookups that found a value.", func(s stats.Snapshot) uint64 { return s.Hits }},
+		{"misses", "Lookups that found nothing.", func(s stats.Snapshot) uint64 { return s.Misses }},
+		{"writes", "Values stored.", func(s stats.Snapshot) uint64 { return s.Writes }},
+		{"deletes", "Values removed on request.", func(s stats.Snapshot) uint64 { return s.Deletes }},
+		{"errors", "Operations that failed.", func(s stats.Snapshot) uint64 { return s.Errors }},
+		{"evictions", "Values removed to make room for others.", func(s stats.Snapshot) uint64 { return s.Evictions }},
+		{"expirations", "Values removed because their TTL elapsed.", func(s stats.Snapshot) uint64 { return s.Expirations }},
+	}
+
+	for _, c := range counters {
+		name := "cache_" + c.name + "_total"
+		writeHeader(bw, name, c.help, "counter")
+		for _, driver := range drivers {
+			writeSample(bw, name, labels("driver", driver), strconv.FormatUint(c.value(all[driver]), 10))
+		}
+	}
+
+	writeHeader(bw, "cache_hit_ratio", "Hits divided by lookups.", "gauge")
+	for _, driver := range drivers {
+		writeSample(bw, "cache_hit_ratio", labels("driver", driver), formatFloat(all[driver].HitRatio()))
+	}
+
+	writeHeader(bw, "cache_operations_total", "Calls per cache operation.", "counter")
+	for _, driver := range drivers {
+		for _, op := range stats.Ops() {
+			if n, ok := all[driver].Ops[op]; ok {
+				writeSample(bw, "cache_operations_total", labels("driver", driver, "op", op.String()), strconv.FormatUint(n, 10))
+			}
+		}
+	}
+
+	writeHeader(bw, "cache_operation_duration_seconds", "Latency of cache operations (sampled on the memory cache).", "histogram")
+	for _, driver := range drivers {
+		for _, op := range stats.Ops() {
+			if h, ok := all[driver].Latency[op]; ok {
+				writeHistogram(bw, "cache_operation_duration_seconds", driver, op.String(), h)
+			}
+		}
+	}
+
+	// Prefix statistics only track per-operation outcomes: hits, misses, writes, deletes and errors
+	for _, c := range counters[:5] {
+		name := "cache_prefix_" + c.name + "_total"
+		written := false
+		for _, driver := range drivers {
+			prefixes := all[driver].Prefixes
+			keys := make([]string, 0, len(prefixes))
+			for prefix := range prefixes {
+				keys = append(keys, prefix)
+			}
+			slices.Sort(keys)
+
+			for _, prefix := range keys {
+				if !written {
+					writeHeader(bw, name, c.help+" Per key prefix.", "counter")
+					written = true
+				}
+				writeSample(bw, name, labels("driver", driver, "prefix", prefix), strconv.FormatUint(c.value(prefixes[prefix]), 10))
+			}
+		}
+	}
+
+	shards := all[MemCache].Usage.Shards
+	writeHeader(bw, "cache_mem_items", "Items stored per memory cache shard, including expired items not yet removed.", "gauge")
+	for i, s := range shards {
+		writeSample(bw, "cache_mem_items", labels("shard", strconv.Itoa(i)), strconv.FormatInt(s.Items+s.Expired, 10))
+	}
+	writeHeader(bw, "cache_mem_expired_items", "Expired items not yet removed per memory cache shard.", "gauge")
+	for i, s := range shards {
+		writeSample(bw, "cache_mem_expired_items", labels("shard", strconv.Itoa(i)), strconv.FormatInt(s.Expired, 10))
+	}
+	writeHeader(bw, "cache_mem_bytes", "Estimated bytes held per memory cache shard.", "gauge")
+	for i, s := range shards {
+		writeSample(bw, "cache_mem_bytes", labels("shard", strconv.Itoa(i)), strconv.FormatInt(s.Bytes, 10))
+	}
+
+	if m.Redis != nil {
+		ps := m.Redis.PoolStats()
+		writeHeader(bw, "cache_redis_pool_active_connections", "Connections in the Redis pool, in use or idle.", "gauge")
+		writeSample(bw, "cache_redis_pool_active_connections", "", strconv.Itoa(ps.ActiveCount))
+		writeHeader(bw, "cache_redis_pool_idle_connections", "Idle connections in the Redis pool.", "gauge")
+		writeSample(bw, "cache_redis_pool_idle_connections", "", strconv.Itoa(ps.IdleCount))
+	}
+
+	return bw.Flush()
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric.
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writeSample writes a single sample line. labels must already be formatted by labels.
+func writeSample(w *bufio.Writer, name, labels, value string) {
+	w.WriteString(name)
+	w.WriteString(labels)
+	w.WriteByte(' ')
+	w.WriteString(value)
+	w.WriteByte('\n')
+}
+
+// writeHistogram writes the cumulative buckets, sum and count of a latency histogram.
+func writeHistogram(w *bufio.Writer, name, driver, op string, h stats.Histogram) {
+	var cumulative uint64
+	for i, bound := range stats.Buckets() {
+		cumulative += h.Counts[i]
+		le := formatFloat(bound.Seconds())
+		writeSample(w, name+"_bucket", labels("driver", driver, "op", op, "le", le), strconv.FormatUint(cumulative, 10))
+	}
+
+	writeSample(w, name+"_bucket", labels("driver", driver, "op", op, "le", "+Inf"), strconv.FormatUint(h.Count, 10))
+	writeSample(w, name+"_sum", labels("driver", driver, "op", op), formatFloat(h.Sum.Seconds()))
+	writeSample(w, name+"_count", labels("driver", driver, "op", op), strconv.FormatUint(h.Count, 10))
+}
+
+// labels formats name/value pairs as a Prometheus label set, e.g. {driver="mem"}.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// labelEscaper escapes label values as required by the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatFloat formats a float sample value.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sk-pkg/cache/stats"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	c, err := New(WithStatsPrefix(stats.PrefixUntil(":")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = c.Put("user:1", "a", 10)
+	_, _ = c.Get("user:1")
+	_, _ = c.Get("user:2")
+
+	rec := httptest.NewRecorder()
+	c.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != metricsContentType {
+		t.Error("Unexpected content type:", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE cache_hits_total counter\n",
+		`cache_hits_total{driver="mem"} 1` + "\n",
+		`cache_misses_total{driver="mem"} 1` + "\n",
+		`cache_hit_ratio{driver="mem"} 0.5` + "\n",
+		`cache_operations_total{driver="mem",op="get"} 2` + "\n",
+		`cache_operations_total{driver="mem",op="put"} 1` + "\n",
+		"# TYPE cache_operation_duration_seconds histogram\n",
+		`cache_prefix_hits_total{driver="mem",prefix="user"} 1` + "\n",
+		`cache_mem_items{shard="0"} `,
+		`cache_mem_expired_items{shard="0"} 0` + "\n",
+		"# TYPE cache_mem_bytes gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics should contain %q, got:\n%s", want, body)
+		}
+	}
+
+	if strings.Contains(body, "cache_redis_pool") {
+		t.Error("Redis pool metrics should only be exported when Redis is configured")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	got := labels("driver", "mem", "prefix", "a\"b\\c\nd")
+	want := `{driver="mem",prefix="a\"b\\c\nd"}`
+	if got != want {
+		t.Errorf("labels() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package cache
+
+import "github.com/sk-pkg/cache/redis"
+
+// Middleware wraps a Cache to add behaviour around its operations, such as
+// logging, metrics, key rewriting or validation.
+//
+// Example:
+//
+//	// Reject empty keys on writes
+//	type validate struct{ cache.Decorator }
+//
+//	func (v validate) Put(key string, value any, seconds int) error {
+//	  if key == "" {
+//	    return errors.New("empty key")
+//	  }
+//	  return v.Cache.Put(key, value, seconds)
+//	}
+//
+//	func Validate(next cache.Cache) cache.Cache {
+//	  return validate{cache.Decorator{Cache: next}}
+//	}
+type Middleware func(next Cache) Cache
+
+// Decorator is an embeddable base for middleware. It forwards every Cache
+// method to the wrapped Cache, so a middleware only implements the methods it
+// changes.
+//
+// Per-call TTL jitter (PutWithJitter, AddWithJitter) and sliding expirations
+// (PutSliding) are not forwarded, so that they cannot bypass a middleware that
+// overrides Put or Add: through a Decorator those calls fall back to Put and
+// Add, only the jitter configured on the driver applies, and sliding data gets
+// a fixed TTL.
+type Decorator struct {
+	Cache
+}
+
+// Ping checks the health of the wrapped cache with its Ping method, so that a
+// circuit breaker around a decorated driver keeps its health check. Caches
+// without one are checked with a lookup, classified like the default probe of
+// NewBreaker.
+func (d Decorator) Ping() error {
+	if p, ok := d.Cache.(pinger); ok {
+		return p.Ping()
+	}
+	return probeLookup(d.Cache, redis.IsUnavailable)
+}
+
+// Exists checks if a key exists in the wrapped cache, reporting its lookup
+// errors when it has an Exists method. A middleware that rewrites keys must
+// override Exists along with Has.
+func (d Decorator) Exists(key string) (bool, error) {
+	if ec, ok := d.Cache.(existsCache); ok {
+		return ec.Exists(key)
+	}
+	return d.Cache.Has(key), nil
+}
+
+// ForgetPrefix removes the keys starting with prefix from the wrapped cache,
+// with its bulk removal when it has one. A middleware that rewrites keys must
+// override ForgetPrefix along with Forget.
+func (d Decorator) ForgetPrefix(prefix string) (int, error) {
+	return forgetPrefix(d.Cache, prefix)
+}
+
+// ForgetPattern removes the keys matching pattern from the wrapped cache,
+// with its bulk removal when it has one. A middleware that rewrites keys must
+// override ForgetPattern along with Forget.
+func (d Decorator) ForgetPattern(pattern string) (int, error) {
+	return forgetPattern(d.Cache, pattern)
+}
+
+// Chain composes middlewares into a single Middleware.
+// The first middleware is the outermost one, so it sees every call first.
+//
+// Parameters:
+//   - mws: The middlewares to compose, outermost first
+//
+// Returns:
+//   - Middleware: The composed middleware
+//
+// Example:
+//
+//	wrapped := cache.Chain(Logging, Validate)(c.Mem) // Logging(Validate(c.Mem))
+func Chain(mws ...Middleware) Middleware {
+	return func(next Cache) Cache {
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i](next)
+		}
+		return next
+	}
+}
+
+// Tracing returns a Middleware that reports every call of the wrapped cache
+// as a span to t. See Traced.
+//
+// Parameters:
+//   - t: The tracer receiving the spans
+//   - store: The driver name reported in SpanInfo.Store
+//
+// Returns:
+//   - Middleware: The tracing middleware
+func Tracing(t Tracer, store string) Middleware {
+	return func(next Cache) Cache {
+		return Traced(next, store, t)
+	}
+}
+
+// WithMiddleware wraps every cache driver with the given middlewares.
+// The first middleware is the outermost one. The wrapped drivers are used by
+// the manager methods and returned by Manager.Store; the Mem and Redis fields
+// keep pointing at the bare drivers. Repeated calls append to the chain.
+//
+// Parameters:
+//   - mws: The middlewares to apply, outermost first
+//
+// Returns:
+//   - Option: A configuration option function
+//
+// Example:
+//
+//	cache.New(cache.WithMiddleware(Logging, Validate))
+func WithMiddleware(mws ...Middleware) Option {
+	return func(o *option) {
+		o.middlewares = append(o.middlewares, mws...)
+	}
+}
+
+// Store returns the cache driver with the given identifier, wrapped with the
+// middlewares configured with WithMiddleware.
+//
+// Parameters:
+//   - driver: The cache driver identifier (MemCache or RedisCache)
+//
+// Returns:
+//   - Cache: The wrapped driver, or nil if the driver is not initialized
+//
+// Example:
+//
+//	err := c.Store(cache.MemCache).Put("config", data, 300)
+func (m *Manager) Store(driver string) Cache {
+	return m.stores[driver]
+}
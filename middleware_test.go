@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sk-pkg/cache/mem"
+	"github.com/sk-pkg/cache/stats"
+)
+
+// countingCache counts Put calls and records the wrapping order.
+type countingCache struct {
+	Decorator
+	name  string
+	order *[]string
+	puts  int
+}
+
+func (c *countingCache) Put(key string, value any, seconds int) error {
+	c.puts++
+	*c.order = append(*c.order, c.name)
+	return c.Cache.Put(key, value, seconds)
+}
+
+// prefixCache rewrites keys of Put and Get.
+type prefixCache struct {
+	Decorator
+	prefix string
+}
+
+func (c prefixCache) Put(key string, value any, seconds int) error {
+	return c.Cache.Put(c.prefix+key, value, seconds)
+}
+
+func (c prefixCache) Get(key string) (any, error) {
+	return c.Cache.Get(c.prefix + key)
+}
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	var counters []*countingCache
+	counting := func(name string) Middleware {
+		return func(next Cache) Cache {
+			cc := &countingCache{Decorator: Decorator{Cache: next}, name: name, order: &order}
+			counters = append(counters, cc)
+			return cc
+		}
+	}
+	tenant := func(next Cache) Cache {
+		return prefixCache{Decorator: Decorator{Cache: next}, prefix: "tenant:"}
+	}
+
+	c, err := New(WithMiddleware(counting("outer"), counting("inner")), WithMiddleware(tenant))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Put("key", "value", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Error("Middlewares should run outermost first, got:", order)
+	}
+
+	if value, _ := c.Get("key"); value != "value" {
+		t.Error("Expected value through the middlewares, got:", value)
+	}
+
+	if !c.Mem.Has("tenant:key") {
+		t.Error("The bare driver should hold the rewritten key")
+	}
+
+	// Per-call jitter falls back to Put so middlewares are not bypassed
+	if err = c.PutWithJitter("other", 1, 10, Jitter{Fraction: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if counters[0].puts != 2 || !c.Mem.Has("tenant:other") {
+		t.Error("PutWithJitter should go through the middlewares")
+	}
+
+	if c.Store(MemCache) == nil || c.Store(RedisCache) != nil {
+		t.Error("Store should return the initialized drivers only")
+	}
+}
+
+func TestChain(t *testing.T) {
+	failing := func(next Cache) Cache {
+		return failingPut{Decorator{Cache: next}}
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrapped := Chain(failing)(c.Mem)
+	if err = wrapped.Put("key", 1, 10); err == nil {
+		t.Error("Expected the middleware error")
+	}
+
+	if err = wrapped.Forever("key", 1); err != nil || !wrapped.Has("key") {
+		t.Error("Decorator should forward methods the middleware does not override")
+	}
+
+	if Chain()(c.Mem) == nil {
+		t.Error("An empty chain should return the cache unchanged")
+	}
+}
+
+func TestMiddlewareForgetPrefix(t *testing.T) {
+	c, err := New(WithMiddleware(func(next Cache) Cache { return Decorator{Cache: next} }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 3 {
+		_ = c.Put("user:42:"+strconv.Itoa(i), i, 0)
+	}
+
+	if removed, err := c.ForgetPrefix("user:42:"); err != nil || removed != 3 {
+		t.Fatalf("Expected ForgetPrefix to remove 3 keys, got %d, %v", removed, err)
+	}
+	if removed, _ := c.ForgetPattern("user:*"); removed != 0 {
+		t.Error("Expected ForgetPattern to find no key left, got:", removed)
+	}
+
+	// The driver removes the keys in bulk instead of one Forget per key
+	s := c.Mem.Stats()
+	if s.Ops[stats.OpForgetMatch] != 2 || s.Ops[stats.OpForget] != 0 {
+		t.Errorf("Expected 2 bulk removals and no single Forget, got %d and %d",
+			s.Ops[stats.OpForgetMatch], s.Ops[stats.OpForget])
+	}
+}
+
+// listingCache counts the calls of the slow removal path: Keys and Forget.
+type listingCache struct {
+	Decorator
+	calls int
+}
+
+func (c *listingCache) Keys(pattern string) ([]string, error) {
+	c.calls++
+	return c.Cache.Keys(pattern)
+}
+
+func (c *listingCache) Forget(key string) (bool, error) {
+	c.calls++
+	return c.Cache.Forget(key)
+}
+
+func TestChainForgetPrefix(t *testing.T) {
+	driver := mem.Init(mem.WithPrefixIndex())
+	defer driver.Close()
+
+	// The outer middleware would see Keys and Forget if the chain fell back to them
+	outer := &listingCache{}
+	wrapped := Chain(
+		func(next Cache) Cache { outer.Cache = next; return outer },
+		func(next Cache) Cache { return Decorator{Cache: next} },
+	)(driver)
+
+	for i := range 3 {
+		_ = wrapped.Put("user:42:"+strconv.Itoa(i), i, 0)
+	}
+	_ = wrapped.Put("user:43:0", 0, 0)
+
+	if removed, err := forgetPrefix(wrapped, "user:42:"); err != nil || removed != 3 {
+		t.Fatalf("Expected ForgetPrefix to remove 3 keys, got %d, %v", removed, err)
+	}
+	if removed, err := forgetPattern(wrapped, "user:4?:*"); err != nil || removed != 1 {
+		t.Fatalf("Expected ForgetPattern to remove 1 key, got %d, %v", removed, err)
+	}
+
+	if outer.calls != 0 {
+		t.Error("Expected the chain to keep the bulk removal of the driver, got Keys and Forget calls:", outer.calls)
+	}
+	if s := driver.Stats(); s.Ops[stats.OpForgetMatch] != 2 || s.Ops[stats.OpScan] != 0 || s.Ops[stats.OpForget] != 0 {
+		t.Errorf("Expected 2 bulk removals on the driver, got %v", s.Ops)
+	}
+}
+
+// failingPut rejects every Put.
+type failingPut struct {
+	Decorator
+}
+
+func (failingPut) Put(string, any, int) error {
+	return errors.New("put rejected")
+}
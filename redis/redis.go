@@ -4,19 +4,99 @@
 package redis
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	redigo "github.com/gomodule/redigo/redis"
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/internal/lifecycle"
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/internal/ttl"
+	"github.com/sk-pkg/cache/stats"
 	"github.com/sk-pkg/redis"
 )
 
+// Jitter describes how much random extra time is added to a TTL.
+// See WithTTLJitter for details.
+type Jitter = ttl.Jitter
+
+// LogLevels holds the level each kind of log record is written at.
+// See WithLogLevels for details.
+type LogLevels = logx.Levels
+
+// DefaultSlowThreshold is the default duration above which an operation is logged as slow.
+const DefaultSlowThreshold = logx.DefaultSlowThreshold
+
+// DefaultBatchSize is the default number of keys per SCAN step and UNLINK command.
+const DefaultBatchSize = 1000
+
+// DefaultUsageSample is the default number of keys Usage inspects with SCAN.
+const DefaultUsageSample = 10_000
+
+// usageMemorySample is the number of keys Usage measures with MEMORY USAGE.
+const usageMemorySample = 100
+
+// ErrClosed is returned by the operations of a cache after Close.
+var ErrClosed = lifecycle.ErrClosed
+
+// slidingHeader starts the values stored with PutSliding. It is followed by the
+// idle time in milliseconds, the deadline in Unix milliseconds (0 = no limit)
+// and the JSON encoded value, separated by ':'. JSON never starts with '~'.
+// The format is private to this driver: other Redis clients reading the key
+// see the header, and INCRBY fails on it (see ErrSlidingValue).
+const slidingHeader = "~sliding:"
+
+// ErrSlidingValue is returned by Increment and Decrement for a key stored with
+// PutSliding, whose value is not a plain integer.
+var ErrSlidingValue = errors.New("redis: cannot increment or decrement a value stored with PutSliding")
+
+// slidingScript returns the value of KEYS[1] and, if it was stored with
+// PutSliding, moves its expiration to its idle time after ARGV[1] (Unix
+// milliseconds), but not past its deadline. Reading and extending in one script
+// keeps a value written meanwhile from getting the expiration of the old one.
+var slidingScript = redigo.NewScript(1, `
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return false
+end
+local idle, deadline = string.match(v, '^~sliding:(%d+):(%d+):')
+if idle then
+	local at = tonumber(ARGV[1]) + tonumber(idle)
+	deadline = tonumber(deadline)
+	if deadline > 0 and deadline < at then
+		at = deadline
+	end
+	redis.call('PEXPIREAT', KEYS[1], string.format('%d', at))
+end
+return v
+`)
+
 // Option is a function type that configures the option struct.
 type Option func(*option)
 
 // option holds configuration parameters for the Redis cache.
 type option struct {
-	prefix       string
-	redisManager *redis.Manager
-	redisConfig  Config
+	prefix        string
+	redisManager  *redis.Manager
+	redisConfig   Config
+	jitter        Jitter
+	prefixes      *stats.Prefixes
+	logger        *slog.Logger
+	logLevels     LogLevels
+	slowThreshold time.Duration
+	batchSize     int
+	deleteRate    int
+	progress      func(removed int)
+	usageSample   int
 }
 
 // Config holds Redis connection configuration parameters.
@@ -28,8 +108,19 @@ type Config struct {
 
 // Cache implements the cache interface using Redis as the storage backend.
 type Cache struct {
-	redis  *redis.Manager // Redis connection manager
-	prefix string         // Key prefix for this cache instance
+	redis  *redis.Manager  // Redis connection manager
+	prefix string          // Key prefix for this cache instance
+	jitter Jitter          // Jitter applied to the TTL of Put and Add
+	stats  *stats.Recorder // Statistics of this cache
+	byKey  *stats.Prefixes // Statistics per key prefix, nil unless enabled
+	log    *logx.Logger    // Structured logger, nil unless enabled
+	owned  bool            // Whether the connection pool was created from Config
+	closed *atomic.Bool    // Set by Close
+
+	batch    int               // Keys per SCAN step and UNLINK command
+	rate     int               // Keys removed per second by bulk deletions (0 = unlimited)
+	progress func(removed int) // Called after every batch of a bulk deletion, nil unless set
+	sample   int               // Keys inspected by Usage
 }
 
 // WithPrefix returns an Option that sets the key prefix for the cache.
@@ -75,6 +166,136 @@ func WithRedisConfig(redisConfig Config) Option {
 	}
 }
 
+// WithTTLJitter returns an Option that adds a random amount of extra time to
+// every TTL passed to Put and Add, so that keys written in bulk with the same
+// TTL do not all expire in the same second. Keys without expiration are not affected.
+//
+// Example:
+//
+//	// Spread expirations by up to 10% of the TTL
+//	cache, _ := Init(WithTTLJitter(Jitter{Fraction: 0.1}))
+func WithTTLJitter(j Jitter) Option {
+	return func(o *option) {
+		o.jitter = j
+	}
+}
+
+// WithStatsPrefix returns an Option that additionally groups statistics by key prefix.
+// The function maps a key to its group; keys mapped to "" are only counted in the totals.
+//
+// Example:
+//
+//	cache, _ := Init(WithStatsPrefix(stats.PrefixUntil(":")))
+func WithStatsPrefix(fn stats.PrefixFunc) Option {
+	return func(o *option) {
+		o.prefixes = stats.NewPrefixes(fn)
+	}
+}
+
+// WithLogger returns an Option that writes structured logs of swallowed errors
+// and slow operations to l. Nothing is logged by default.
+//
+// Example:
+//
+//	cache, _ := Init(WithLogger(slog.Default()))
+func WithLogger(l *slog.Logger) Option {
+	return func(o *option) {
+		o.logger = l
+	}
+}
+
+// WithLogLevels returns an Option that sets the level each kind of log record
+// is written at. By default swallowed errors and slow operations are warnings.
+//
+// Example:
+//
+//	levels := DefaultLogLevels()
+//	levels.Error = slog.LevelError
+//	cache, _ := Init(WithLogger(logger), WithLogLevels(levels))
+func WithLogLevels(levels LogLevels) Option {
+	return func(o *option) {
+		o.logLevels = levels
+	}
+}
+
+// WithSlowThreshold returns an Option that logs operations taking longer than d
+// (default DefaultSlowThreshold, 0 disables).
+//
+// Example:
+//
+//	cache, _ := Init(WithLogger(logger), WithSlowThreshold(50*time.Millisecond))
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *option) {
+		o.slowThreshold = d
+	}
+}
+
+// WithBatchSize returns an Option that sets the number of keys Keys, Flush,
+// ForgetPrefix and ForgetPattern request per SCAN step and remove per UNLINK
+// command (default DefaultBatchSize). Smaller batches keep every command short
+// at the cost of more round trips. Values below 1 keep the default.
+//
+// Example:
+//
+//	cache, _ := Init(WithRedisConfig(config), WithBatchSize(200))
+func WithBatchSize(n int) Option {
+	return func(o *option) {
+		if n > 0 {
+			o.batchSize = n
+		}
+	}
+}
+
+// WithDeleteRate returns an Option that limits Flush, ForgetPrefix and
+// ForgetPattern to removing about n keys per second, so that deleting millions
+// of keys does not saturate Redis. The limit is applied between batches.
+// A non-positive n removes the limit (the default).
+//
+// Example:
+//
+//	cache, _ := Init(WithRedisConfig(config), WithDeleteRate(100_000))
+func WithDeleteRate(n int) Option {
+	return func(o *option) {
+		o.deleteRate = max(n, 0)
+	}
+}
+
+// WithDeleteProgress returns an Option that calls fn after every batch removed
+// by Flush, ForgetPrefix and ForgetPattern, with the number of keys removed so
+// far by the call. fn runs on the goroutine of the call and delays the next batch.
+//
+// Example:
+//
+//	cache, _ := Init(WithRedisConfig(config), WithDeleteProgress(func(removed int) {
+//	    log.Printf("removed %d keys", removed)
+//	}))
+func WithDeleteProgress(fn func(removed int)) Option {
+	return func(o *option) {
+		o.progress = fn
+	}
+}
+
+// WithUsageSample returns an Option that sets how many keys Usage and Len
+// inspect with SCAN to estimate the share of the database under the prefix
+// (default DefaultUsageSample). Larger samples are more accurate but take
+// longer. Values below 1 keep the default.
+//
+// Example:
+//
+//	cache, _ := Init(WithRedisConfig(config), WithUsageSample(100_000))
+func WithUsageSample(n int) Option {
+	return func(o *option) {
+		if n > 0 {
+			o.usageSample = n
+		}
+	}
+}
+
+// DefaultLogLevels returns the log levels used unless WithLogLevels is given.
+func DefaultLogLevels() LogLevels {
+	return logx.DefaultLevels()
+}
+
 // Init creates and initializes a new Redis cache with the provided options.
 // It returns a pointer to the initialized Cache and any error encountered.
 //
@@ -96,7 +317,7 @@ func WithRedisConfig(redisConfig Config) Option {
 //	redisManager := redis.New(...)
 //	cache, err := redis.Init(redis.WithRedisManager(redisManager))
 func Init(opts ...Option) (*Cache, error) {
-	opt := &option{}
+	opt := &option{logLevels: logx.DefaultLevels(), slowThreshold: DefaultSlowThreshold, batchSize: DefaultBatchSize, usageSample: DefaultUsageSample}
 	// Apply all provided options to the option struct
 	for _, f := range opts {
 		f(opt)
@@ -116,11 +337,51 @@ func Init(opts ...Option) (*Cache, error) {
 	rdsCache := &Cache{
 		redis:  redisManager,
 		prefix: opt.prefix,
+		jitter: opt.jitter,
+		stats:  &stats.Recorder{},
+		byKey:  opt.prefixes,
+		log:    logx.New(opt.logger, opt.logLevels, opt.slowThreshold),
+		owned:  opt.redisConfig.Address != "",
+		closed: &atomic.Bool{},
+
+		batch:    opt.batchSize,
+		rate:     opt.deleteRate,
+		progress: opt.progress,
+		sample:   opt.usageSample,
 	}
 
 	return rdsCache, nil
 }
 
+// Close releases the connection pool if it was created from the Config given
+// with WithRedisConfig; a manager given with WithRedisManager belongs to the
+// caller and is left open. Afterwards every operation returns ErrClosed and
+// Has reports false. Closing a cache more than once is a no-op.
+//
+// Returns:
+//   - error: Any error encountered while closing the connection pool
+//
+// Example:
+//
+//	cache, _ := redis.Init(redis.WithRedisConfig(config))
+//	defer cache.Close()
+func (c Cache) Close() error {
+	if c.closed == nil || !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	if c.owned {
+		return c.redis.ConnPool.Close()
+	}
+
+	return nil
+}
+
+// isClosed reports whether Close was called.
+func (c Cache) isClosed() bool {
+	return c.closed != nil && c.closed.Load()
+}
+
 // Put stores a value in the cache for the specified duration in seconds.
 // If seconds is 0, the value will be stored indefinitely.
 //
@@ -136,11 +397,39 @@ func Init(opts ...Option) (*Cache, error) {
 //
 //	err := cache.Put("user:123", userData, 3600) // Store for 1 hour
 func (c Cache) Put(key string, value any, seconds int) error {
-	return c.redis.Set(c.prefix+key, value, seconds)
+	return c.PutWithJitter(key, value, seconds, c.jitter)
+}
+
+// PutWithJitter stores a value like Put, but uses the given jitter instead of
+// the one configured with WithTTLJitter. Pass a zero Jitter to disable it for this call.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store (will be JSON encoded)
+//   - seconds: The time-to-live in seconds (0 for indefinite)
+//   - j: The jitter applied to this TTL
+//
+// Returns:
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	err := cache.PutWithJitter("user:123", userData, 3600, redis.Jitter{Max: time.Minute})
+func (c Cache) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	start := time.Now()
+	err := c.set(key, value, seconds, j)
+	c.record(key, stats.OpPut, start, outcome(err, stats.Write))
+
+	return err
 }
 
 // Add stores a value in the cache only if the key does not already exist.
-// If the key exists, the operation is a no-op and returns nil.
+// If the key exists, the operation is a no-op and returns nil. The check and
+// the write are a single SET NX, so concurrent Adds store one value only.
 //
 // Parameters:
 //   - key: The key under which to store the value
@@ -155,16 +444,126 @@ func (c Cache) Put(key string, value any, seconds int) error {
 //	// Only sets the value if "user:123" doesn't exist
 //	err := cache.Add("user:123", userData, 3600)
 func (c Cache) Add(key string, value any, seconds int) error {
-	// Only set the value if the key doesn't exist
-	if !c.Has(key) {
-		return c.redis.Set(c.prefix+key, value, seconds)
+	return c.AddWithJitter(key, value, seconds, c.jitter)
+}
+
+// AddWithJitter adds a value like Add, but uses the given jitter instead of
+// the one configured with WithTTLJitter. Pass a zero Jitter to disable it for this call.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store (will be JSON encoded)
+//   - seconds: The time-to-live in seconds (0 for indefinite)
+//   - j: The jitter applied to this TTL
+//
+// Returns:
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	err := cache.AddWithJitter("user:123", userData, 3600, redis.Jitter{Fraction: 0.05})
+func (c Cache) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	_, err := c.TryAddWithJitter(key, value, seconds, j)
+	return err
+}
+
+// TryAdd adds a value like Add and reports whether it was stored, i.e. whether
+// the key was absent, as answered by SET NX.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store (will be JSON encoded)
+//   - seconds: The time-to-live in seconds (0 for indefinite)
+//
+// Returns:
+//   - bool: true if the value was stored, false if the key exists or on error
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	if added, _ := cache.TryAdd("lock:job", owner, 30); added {
+//	    // This caller holds the lock
+//	}
+func (c Cache) TryAdd(key string, value any, seconds int) (bool, error) {
+	return c.TryAddWithJitter(key, value, seconds, c.jitter)
+}
+
+// TryAddWithJitter adds a value like AddWithJitter and reports whether it was
+// stored, see TryAdd.
+func (c Cache) TryAddWithJitter(key string, value any, seconds int, j Jitter) (bool, error) {
+	if c.isClosed() {
+		return false, ErrClosed
 	}
 
-	return nil
+	start := time.Now()
+	written, err := c.setNX(key, value, seconds, j)
+	if !written {
+		c.record(key, stats.OpAdd, start, outcome(err, stats.None))
+		return false, err
+	}
+	c.record(key, stats.OpAdd, start, stats.Write)
+
+	return true, nil
+}
+
+// PutSliding stores a value that expires once it has not been read for idle,
+// e.g. a session expiring after 30 minutes of inactivity. Every Get that finds
+// the value extends its expiration to idle from now; Has and Exists do not.
+// Storing the key again with Put ends the sliding expiration.
+//
+// The idle time is stored in front of the JSON encoded value, so that every
+// instance sharing the keys extends it alike. Get reads every value with a Lua
+// script that extends sliding values in the same round trip. The format is
+// only understood by this driver: other Redis clients see the header, and
+// Increment and Decrement return ErrSlidingValue.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store (will be JSON encoded)
+//   - idle: How long the value lives without being read (0 for indefinite)
+//
+// Returns:
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	err := cache.PutSliding("session:abc", session, 30*time.Minute)
+func (c Cache) PutSliding(key string, value any, idle time.Duration) error {
+	return c.PutSlidingWithLifetime(key, value, idle, 0)
+}
+
+// PutSlidingWithLifetime stores a value like PutSliding, but never extends its
+// expiration past lifetime from now, however often it is read.
+//
+// Parameters:
+//   - key: The key under which to store the value
+//   - value: The value to store (will be JSON encoded)
+//   - idle: How long the value lives without being read (0 for indefinite)
+//   - lifetime: The maximum time the value lives (0 for no limit)
+//
+// Returns:
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	// Log out after 30 minutes of inactivity, and after 12 hours in any case
+//	err := cache.PutSlidingWithLifetime("session:abc", session, 30*time.Minute, 12*time.Hour)
+func (c Cache) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	start := time.Now()
+	err := c.setSliding(key, value, idle, lifetime)
+	c.record(key, stats.OpPut, start, outcome(err, stats.Write))
+
+	return err
 }
 
 // Get retrieves a value from the cache.
 // If the key does not exist or has expired, it returns nil and an error.
+// Values are read with a script that also extends the expiration of values
+// stored with PutSliding, in a single round trip.
 //
 // Parameters:
 //   - key: The key to retrieve
@@ -181,15 +580,38 @@ func (c Cache) Add(key string, value any, seconds int) error {
 //	}
 //	userData := value.(map[string]interface{})
 func (c Cache) Get(key string) (any, error) {
-	// Get the raw bytes from Redis
-	bytes, err := c.redis.Get(c.prefix + key)
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+
+	start := time.Now()
+	value, err := c.get(key, true)
+	c.record(key, stats.OpGet, start, lookupOutcome(err))
+
+	return value, err
+}
+
+// get retrieves and decodes a value without recording statistics. If extend
+// is set, the value is read with slidingScript, which extends the expiration of
+// a sliding value in the same round trip.
+func (c Cache) get(key string, extend bool) (any, error) {
+	var bytes []byte
+	var err error
+	if extend {
+		conn := c.redis.ConnPool.Get()
+		defer conn.Close()
+
+		bytes, err = redigo.Bytes(slidingScript.Do(conn, c.rawKey(key), time.Now().UnixMilli()))
+	} else {
+		bytes, err = c.redis.Get(c.prefix + key)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// Unmarshal the JSON data
 	var value any
-	err = json.Unmarshal(bytes, &value)
+	err = json.Unmarshal(slidingValue(bytes), &value)
 	if err != nil {
 		return nil, err
 	}
@@ -212,18 +634,28 @@ func (c Cache) Get(key string) (any, error) {
 //	// Get the value and remove it in one operation
 //	value, err := cache.Pull("user:123")
 func (c Cache) Pull(key string) (any, error) {
-	// Get the value first
-	value, err := c.Get(key)
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+
+	start := time.Now()
+
+	// Get the value first, there is no point in extending it
+	value, err := c.get(key, false)
 	if err != nil {
+		c.record(key, stats.OpPull, start, lookupOutcome(err))
 		return nil, err
 	}
 
 	// Then delete the key
-	_, err = c.Forget(key)
+	_, err = c.redis.Del(c.prefix + key)
 	if err != nil {
+		c.record(key, stats.OpPull, start, stats.Hit|stats.Error)
 		return nil, err
 	}
 
+	c.record(key, stats.OpPull, start, stats.Hit|stats.Delete)
+
 	return value, nil
 }
 
@@ -241,10 +673,114 @@ func (c Cache) Pull(key string) (any, error) {
 //	    // Key exists
 //	}
 func (c Cache) Has(key string) bool {
-	exists, _ := c.redis.Exists(c.prefix + key)
+	exists, err := c.Exists(key)
+	if err != nil && !errors.Is(err, ErrClosed) {
+		c.log.Error("cache lookup failed", err,
+			slog.String("store", "redis"),
+			slog.String("operation", stats.OpHas.String()),
+			slog.String("key", key),
+		)
+	}
 	return exists
 }
 
+// Exists checks if a key exists in the cache.
+// Unlike Has, it reports the error when Redis could not be queried.
+//
+// Parameters:
+//   - key: The key to check
+//
+// Returns:
+//   - bool: true if the key exists, false otherwise
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	exists, err := cache.Exists("user:123")
+func (c Cache) Exists(key string) (bool, error) {
+	if c.isClosed() {
+		return false, ErrClosed
+	}
+
+	start := time.Now()
+	exists, err := c.redis.Exists(c.prefix + key)
+	c.record(key, stats.OpHas, start, outcome(err, stats.None))
+
+	return exists, err
+}
+
+// Ping checks that the Redis server is reachable.
+//
+// Returns:
+//   - error: Any error encountered while sending PING
+//
+// Example:
+//
+//	if err := cache.Ping(); err != nil {
+//	    // Redis is down
+//	}
+func (c Cache) Ping() error {
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	// Get a connection from the pool
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("PING")
+	return err
+}
+
+// IsMiss reports whether err means that the key does not exist.
+// Get and Pull return such an error for missing keys.
+//
+// Parameters:
+//   - err: The error returned by a cache operation
+//
+// Returns:
+//   - bool: true if the error is a cache miss
+//
+// Example:
+//
+//	value, err := cache.Get("user:123")
+//	if redis.IsMiss(err) {
+//	    // Load from the data source
+//	}
+func IsMiss(err error) bool {
+	return errors.Is(err, redigo.ErrNil)
+}
+
+// IsUnavailable reports whether err means that Redis could not be reached,
+// as opposed to a cache miss, a server-side error reply or a decoding error.
+//
+// Parameters:
+//   - err: The error returned by a cache operation
+//
+// Returns:
+//   - bool: true if the error is caused by a network or connection pool failure
+//
+// Example:
+//
+//	if _, err := cache.Get("user:123"); redis.IsUnavailable(err) {
+//	    // Fall back to another store
+//	}
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, redigo.ErrPoolExhausted) ||
+		errors.Is(err, net.ErrClosed)
+}
+
 // Forever stores a value in the cache indefinitely (without expiration).
 // This is equivalent to calling Put with seconds=0.
 //
@@ -259,7 +795,15 @@ func (c Cache) Has(key string) bool {
 //
 //	err := cache.Forever("app:config", configData)
 func (c Cache) Forever(key string, value any) error {
-	return c.redis.Set(c.prefix+key, value, 0)
+	if c.isClosed() {
+		return ErrClosed
+	}
+
+	start := time.Now()
+	err := c.redis.Set(c.prefix+key, value, 0)
+	c.record(key, stats.OpForever, start, outcome(err, stats.Write))
+
+	return err
 }
 
 // Forget removes a key from the cache.
@@ -275,11 +819,25 @@ func (c Cache) Forever(key string, value any) error {
 //
 //	removed, err := cache.Forget("user:123")
 func (c Cache) Forget(key string) (bool, error) {
-	return c.redis.Del(c.prefix + key)
+	if c.isClosed() {
+		return false, ErrClosed
+	}
+
+	start := time.Now()
+	removed, err := c.redis.Del(c.prefix + key)
+
+	o := stats.None
+	if removed {
+		o = stats.Delete
+	}
+	c.record(key, stats.OpForget, start, outcome(err, o))
+
+	return removed, err
 }
 
 // Increment atomically increments the integer value of a key by the given amount.
-// If the key does not exist, it is set to the amount.
+// If the key does not exist, it is set to the amount. Keys stored with
+// PutSliding return ErrSlidingValue.
 //
 // Parameters:
 //   - key: The key to increment
@@ -294,16 +852,20 @@ func (c Cache) Forget(key string) (bool, error) {
 //	newValue, err := cache.Increment("visits", 1)
 //	// newValue is the updated counter
 func (c Cache) Increment(key string, n int) (int, error) {
-	// Get a connection from the pool
-	conn := c.redis.ConnPool.Get()
-	defer conn.Close()
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
 
-	// Execute INCRBY command and return the result
-	return redigo.Int(conn.Do("INCRBY", c.prefix+key, n))
+	start := time.Now()
+	value, err := c.incrBy("INCRBY", key, n)
+	c.record(key, stats.OpIncrement, start, outcome(err, stats.Write))
+
+	return value, err
 }
 
 // Decrement atomically decrements the integer value of a key by the given amount.
-// If the key does not exist, it is set to the negative of the amount.
+// If the key does not exist, it is set to the negative of the amount. Keys
+// stored with PutSliding return ErrSlidingValue.
 //
 // Parameters:
 //   - key: The key to decrement
@@ -318,16 +880,39 @@ func (c Cache) Increment(key string, n int) (int, error) {
 //	newValue, err := cache.Decrement("remaining", 1)
 //	// newValue is the updated counter
 func (c Cache) Decrement(key string, n int) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+
+	start := time.Now()
+	value, err := c.incrBy("DECRBY", key, n)
+	c.record(key, stats.OpDecrement, start, outcome(err, stats.Write))
+
+	return value, err
+}
+
+// incrBy runs cmd (INCRBY or DECRBY) on key. Sliding values fail as any value
+// that is not an integer does; that failure is reported as ErrSlidingValue.
+func (c Cache) incrBy(cmd, key string, n int) (int, error) {
 	// Get a connection from the pool
 	conn := c.redis.ConnPool.Get()
 	defer conn.Close()
 
-	// Execute DECRBY command and return the result
-	return redigo.Int(conn.Do("DECRBY", c.prefix+key, n))
+	value, err := redigo.Int(conn.Do(cmd, c.rawKey(key), n))
+	var rerr redigo.Error
+	if errors.As(err, &rerr) {
+		head, _ := redigo.Bytes(conn.Do("GETRANGE", c.rawKey(key), 0, len(slidingHeader)-1))
+		if string(head) == slidingHeader {
+			return 0, fmt.Errorf("%w: %s", ErrSlidingValue, key)
+		}
+	}
+
+	return value, err
 }
 
 // Flush removes all keys with the cache prefix from Redis.
 // This effectively clears the entire cache.
+// See FlushContext for how keys are found and removed.
 //
 // Returns:
 //   - error: Any error encountered during the operation
@@ -337,5 +922,608 @@ func (c Cache) Decrement(key string, n int) (int, error) {
 //	err := cache.Flush()
 //	// All keys with the cache prefix are now removed
 func (c Cache) Flush() error {
-	return c.redis.BatchDel(c.prefix)
+	_, err := c.FlushContext(context.Background())
+	return err
+}
+
+// FlushContext removes all keys with the cache prefix from Redis, stopping when
+// ctx is done. Like ForgetPatternContext, it removes the keys in batches with
+// SCAN and UNLINK instead of blocking Redis with KEYS, paces them to
+// WithDeleteRate and reports them to WithDeleteProgress.
+//
+// Parameters:
+//   - ctx: The context that cancels the flush between two batches
+//
+// Returns:
+//   - int: The number of keys removed, including the batches removed before an error
+//   - error: ctx.Err() if ctx is done first, or any error encountered during the operation
+//
+// Example:
+//
+//	cache, _ := redis.Init(
+//	    redis.WithRedisConfig(config),
+//	    redis.WithBatchSize(500),
+//	    redis.WithDeleteRate(50_000),
+//	    redis.WithDeleteProgress(func(removed int) {
+//	        log.Printf("flushed %d keys", removed)
+//	    }),
+//	)
+//	removed, err := cache.FlushContext(ctx)
+func (c Cache) FlushContext(ctx context.Context) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+
+	start := time.Now()
+	removed, err := c.unlink(ctx, "*")
+	c.recordDelete(stats.OpFlush, "", start, removed, err)
+
+	return removed, err
+}
+
+// Keys returns the keys matching a Redis glob pattern, without the cache
+// prefix; an empty pattern matches every key. It iterates with SCAN instead of
+// KEYS, so Redis keeps serving other clients meanwhile, and a key written or
+// removed during the call may or may not be returned.
+//
+// Parameters:
+//   - pattern: The glob pattern the keys must match, applied after the prefix
+//
+// Returns:
+//   - []string: The matching keys, in no particular order
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	keys, err := cache.Keys("user:*")
+//	// keys holds e.g. "user:1", stored in Redis as "app:user:1"
+func (c Cache) Keys(pattern string) ([]string, error) {
+	if c.isClosed() {
+		return nil, ErrClosed
+	}
+
+	start := time.Now()
+
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	var keys []string
+	var cursor uint64
+	for {
+		found, next, err := c.scan(conn, cursor, pattern, c.batch)
+		if err != nil {
+			c.recordScan(start, err)
+			return nil, err
+		}
+		keys = append(keys, found...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	c.recordScan(start, nil)
+
+	return keys, nil
+}
+
+// Scan runs one step of a SCAN iteration over the keys matching a Redis glob
+// pattern, without the cache prefix. Start with cursor 0 and pass the returned
+// cursor to the next call until it is 0 again. Redis treats count as a hint, so
+// a call may return more or fewer keys, and a key may be returned more than once.
+//
+// Parameters:
+//   - cursor: 0 to start an iteration, or the cursor returned by the previous call
+//   - pattern: The glob pattern the keys must match, empty to match every key
+//   - count: The COUNT hint of SCAN (the Redis default if not positive)
+//
+// Returns:
+//   - []string: The matching keys found by this step
+//   - uint64: The cursor of the next call, 0 when the iteration is complete
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	var cursor uint64
+//	for {
+//	    keys, next, err := cache.Scan(cursor, "session:*", 100)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(keys)
+//	    if cursor = next; cursor == 0 {
+//	        break
+//	    }
+//	}
+func (c Cache) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	if c.isClosed() {
+		return nil, 0, ErrClosed
+	}
+
+	start := time.Now()
+
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	keys, next, err := c.scan(conn, cursor, pattern, count)
+	c.recordScan(start, err)
+
+	return keys, next, err
+}
+
+// scan runs a SCAN command matching pattern under the key prefix and strips
+// the prefix from the keys found.
+func (c Cache) scan(conn redigo.Conn, cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	prefix := c.redis.Prefix + c.prefix
+	keys, next, err := c.scanRaw(conn, cursor, pattern, count)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+
+	return keys, next, err
+}
+
+// scanRaw runs a SCAN command matching pattern under the key prefix and
+// returns the keys found as stored in Redis.
+func (c Cache) scanRaw(conn redigo.Conn, cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	args := redigo.Args{cursor, "MATCH", glob.Escape(c.redis.Prefix+c.prefix) + pattern}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+
+	return scanReply(conn.Do("SCAN", args...))
+}
+
+// scanReply parses the reply of a SCAN command into the keys found and the next cursor.
+func scanReply(r any, err error) ([]string, uint64, error) {
+	reply, err := redigo.Values(r, err)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(reply) != 2 {
+		return nil, 0, fmt.Errorf("redis: unexpected SCAN reply of length %d", len(reply))
+	}
+
+	next, err := redigo.Uint64(reply[0], nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	keys, err := redigo.Strings(reply[1], nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, next, nil
+}
+
+// ForgetPrefix removes every key starting with prefix (after the cache
+// prefix), e.g. all the keys of a user. See ForgetPatternContext for how keys
+// are found and removed.
+//
+// Parameters:
+//   - prefix: The prefix of the keys to remove, empty to remove every key of the cache
+//
+// Returns:
+//   - int: The number of keys removed
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	removed, err := cache.ForgetPrefix("user:42:")
+func (c Cache) ForgetPrefix(prefix string) (int, error) {
+	return c.ForgetPrefixContext(context.Background(), prefix)
+}
+
+// ForgetPrefixContext is ForgetPrefix stopping when ctx is done.
+// See ForgetPatternContext for how keys are found and removed.
+//
+// Parameters:
+//   - ctx: The context that cancels the deletion between two batches
+//   - prefix: The prefix of the keys to remove, empty to remove every key of the cache
+//
+// Returns:
+//   - int: The number of keys removed, including after an error
+//   - error: ctx.Err() if ctx is done first, or any error encountered during the operation
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	removed, err := cache.ForgetPrefixContext(ctx, "user:42:")
+func (c Cache) ForgetPrefixContext(ctx context.Context, prefix string) (int, error) {
+	return c.ForgetPatternContext(ctx, glob.Escape(prefix)+"*")
+}
+
+// ForgetPattern removes every key matching a Redis glob pattern (after the
+// cache prefix); an empty pattern matches every key of the cache.
+// See ForgetPatternContext for how keys are found and removed.
+//
+// Parameters:
+//   - pattern: The glob pattern of the keys to remove
+//
+// Returns:
+//   - int: The number of keys removed
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	removed, err := cache.ForgetPattern("user:*:permissions")
+func (c Cache) ForgetPattern(pattern string) (int, error) {
+	return c.ForgetPatternContext(context.Background(), pattern)
+}
+
+// ForgetPatternContext removes every key matching a Redis glob pattern (after
+// the cache prefix), stopping when ctx is done. Keys are found with SCAN and
+// removed with UNLINK, one batch per SCAN step (see WithBatchSize), so Redis
+// keeps serving other clients and frees the memory in the background. The
+// batches are paced to WithDeleteRate, and WithDeleteProgress is told about
+// every batch. UNLINK requires Redis 4.0 or later. A key written during the
+// call may survive.
+//
+// Parameters:
+//   - ctx: The context that cancels the deletion between two batches
+//   - pattern: The glob pattern of the keys to remove, empty to match every key of the cache
+//
+// Returns:
+//   - int: The number of keys removed, including the batches removed before an error
+//   - error: ctx.Err() if ctx is done first, or any error encountered during the operation
+//
+// Example:
+//
+//	removed, err := cache.ForgetPatternContext(ctx, "user:*:permissions")
+func (c Cache) ForgetPatternContext(ctx context.Context, pattern string) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+
+	start := time.Now()
+	removed, err := c.unlink(ctx, pattern)
+	c.recordDelete(stats.OpForgetMatch, pattern, start, removed, err)
+
+	return removed, err
+}
+
+// unlink removes the keys matching pattern under the key prefix with SCAN and
+// UNLINK, one batch at a time, until the iteration completes or ctx is done.
+func (c Cache) unlink(ctx context.Context, pattern string) (int, error) {
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	start := time.Now()
+	removed := 0
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+
+		keys, next, err := c.scanRaw(conn, cursor, pattern, c.batch)
+		if err != nil {
+			return removed, err
+		}
+		if len(keys) > 0 {
+			n, err := redigo.Int(conn.Do("UNLINK", redigo.Args{}.AddFlat(keys)...))
+			removed += n
+			if err != nil {
+				return removed, err
+			}
+			if c.progress != nil {
+				c.progress(removed)
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return removed, nil
+		}
+
+		if err := c.pace(ctx, start, removed); err != nil {
+			return removed, err
+		}
+	}
+}
+
+// pace waits until removing n keys since start no longer exceeds the delete
+// rate, or until ctx is done.
+func (c Cache) pace(ctx context.Context, start time.Time, n int) error {
+	if c.rate <= 0 {
+		return nil
+	}
+
+	wait := time.Until(start.Add(time.Duration(n) * time.Second / time.Duration(c.rate)))
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// recordDelete records a bulk deletion in the cache statistics.
+func (c Cache) recordDelete(op stats.Op, pattern string, start time.Time, removed int, err error) {
+	d := time.Since(start)
+	c.stats.Record(op, d, outcome(err, stats.None))
+	c.stats.Delete(removed)
+	c.log.Slow("redis", op.String(), pattern, d)
+}
+
+// recordScan records a Keys or Scan call in the cache statistics.
+func (c Cache) recordScan(start time.Time, err error) {
+	d := time.Since(start)
+	c.stats.Record(stats.OpScan, d, outcome(err, stats.None))
+	c.log.Slow("redis", stats.OpScan.String(), "", d)
+}
+
+// Stats returns the statistics of the cache.
+// Only operations performed through this instance are counted.
+//
+// Returns:
+//   - stats.Snapshot: Hits, misses, writes, deletes, errors and latencies
+//
+// Example:
+//
+//	s := cache.Stats()
+//	fmt.Printf("hit ratio: %.2f\n", s.HitRatio())
+func (c Cache) Stats() stats.Snapshot {
+	if c.stats == nil {
+		return stats.Snapshot{}
+	}
+
+	s := c.stats.Snapshot()
+	s.Prefixes = c.byKey.Snapshot()
+
+	return s
+}
+
+// Len returns the number of keys under the cache prefix. See Usage for how it
+// is counted; the result is an estimate when Usage reports Sampled.
+//
+// Returns:
+//   - int: The number of keys
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	n, err := cache.Len()
+func (c Cache) Len() (int, error) {
+	u, err := c.Usage()
+	return int(u.Items), err
+}
+
+// Usage estimates how many keys the cache holds under its prefix and how many
+// bytes they take in Redis, without walking the whole keyspace. Without a
+// prefix the number of keys is DBSIZE. Otherwise SCAN inspects up to
+// WithUsageSample keys of the database and the share of those under the prefix
+// is applied to DBSIZE; the count is exact when the sample covers every key.
+// The bytes are extrapolated from MEMORY USAGE on up to 100 of the keys found,
+// which requires Redis 4.0 or later. Sampled is set when either figure is an
+// estimate.
+//
+// Returns:
+//   - stats.Usage: The keys and bytes under the prefix (Expired and Shards are not set)
+//   - error: Any error encountered during the operation
+//
+// Example:
+//
+//	u, err := cache.Usage()
+//	fmt.Printf("~%d keys, ~%d bytes\n", u.Items, u.Bytes)
+func (c Cache) Usage() (stats.Usage, error) {
+	if c.isClosed() {
+		return stats.Usage{}, ErrClosed
+	}
+
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	total, err := redigo.Int64(conn.Do("DBSIZE"))
+	if err != nil {
+		return stats.Usage{}, err
+	}
+
+	// Sample the keyspace, keeping the keys under the prefix to measure
+	prefix := c.redis.Prefix + c.prefix
+	var u stats.Usage
+	var seen, matched int64
+	var measure []string
+	var cursor uint64
+	for {
+		keys, next, err := scanReply(conn.Do("SCAN", cursor, "COUNT", max(c.batch, 1)))
+		if err != nil {
+			return stats.Usage{}, err
+		}
+		for _, key := range keys {
+			seen++
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			matched++
+			if len(measure) < usageMemorySample {
+				measure = append(measure, key)
+			}
+		}
+
+		if cursor = next; cursor == 0 || seen >= int64(c.sample) {
+			break
+		}
+	}
+
+	switch {
+	case prefix == "":
+		u.Items = total
+	case cursor == 0:
+		u.Items = matched
+	case seen > 0:
+		u.Items = matched * total / seen
+		u.Sampled = true
+	}
+
+	var size, n int64
+	for _, key := range measure {
+		b, err := redigo.Int64(conn.Do("MEMORY", "USAGE", key))
+		if errors.Is(err, redigo.ErrNil) {
+			continue // Removed since the scan
+		}
+		if err != nil {
+			return stats.Usage{}, err
+		}
+		size += b
+		n++
+	}
+	if n > 0 {
+		u.Bytes = size * u.Items / n
+		u.Sampled = u.Sampled || n < u.Items
+	}
+
+	return u, nil
+}
+
+// PoolStats returns the statistics of the Redis connection pool,
+// such as the number of active and idle connections.
+//
+// Returns:
+//   - redigo.PoolStats: A snapshot of the connection pool statistics
+//
+// Example:
+//
+//	ps := cache.PoolStats()
+//	fmt.Println(ps.ActiveCount, ps.IdleCount)
+func (c Cache) PoolStats() redigo.PoolStats {
+	return c.redis.ConnPool.Stats()
+}
+
+// record records the outcome of an operation on key in the cache statistics
+// and, when enabled, in the statistics of the key prefix.
+func (c Cache) record(key string, op stats.Op, start time.Time, o stats.Outcome) {
+	d := time.Since(start)
+	c.stats.Record(op, d, o)
+	c.byKey.For(key).Record(op, d, o)
+	c.log.Slow("redis", op.String(), key, d)
+}
+
+// outcome returns stats.Error if err is set, and o otherwise.
+func outcome(err error, o stats.Outcome) stats.Outcome {
+	if err != nil {
+		return stats.Error
+	}
+	return o
+}
+
+// lookupOutcome returns the outcome of a lookup: a missing key (redigo.ErrNil)
+// is a miss, any other error is an error.
+func lookupOutcome(err error) stats.Outcome {
+	if IsMiss(err) {
+		return stats.Miss
+	}
+	return outcome(err, stats.Hit)
+}
+
+// rawKey returns the full Redis key of key, with the prefix of the Redis
+// manager, for commands sent on a connection of the pool. The manager methods
+// add that prefix themselves.
+func (c Cache) rawKey(key string) string {
+	return c.redis.Prefix + c.prefix + key
+}
+
+// set stores a JSON encoded value with a jittered TTL.
+// Whole-second TTLs go through the Redis manager (SET EX), anything finer
+// is written with millisecond precision (SET PX).
+func (c Cache) set(key string, value any, seconds int, j Jitter) error {
+	d := j.Apply(time.Duration(seconds) * time.Second)
+	if d%time.Second == 0 {
+		return c.redis.Set(c.prefix+key, value, int(d/time.Second))
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// Get a connection from the pool
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", c.rawKey(key), data, "PX", d.Milliseconds())
+	return err
+}
+
+// setNX stores a JSON encoded value with a jittered TTL only if key does not
+// exist, with a single SET NX so that no other write can slip in between the
+// check and the write. It reports whether the value was written.
+func (c Cache) setNX(key string, value any, seconds int, j Jitter) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	args := []any{c.rawKey(key), data, "NX"}
+	if d := j.Apply(time.Duration(seconds) * time.Second); d > 0 {
+		args = append(args, "PX", max(d.Milliseconds(), 1))
+	}
+
+	// Get a connection from the pool
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	// A nil reply means the key already exists
+	reply, err := conn.Do("SET", args...)
+	return reply != nil && err == nil, err
+}
+
+// setSliding stores a JSON encoded value behind the sliding header, which
+// slidingScript reads to extend its expiration. Without an idle time, the value
+// is stored as by Put with the lifetime as TTL.
+func (c Cache) setSliding(key string, value any, idle, lifetime time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	ttl := lifetime
+	if idle > 0 {
+		var deadline int64
+		if lifetime > 0 {
+			deadline = time.Now().Add(lifetime).UnixMilli()
+		}
+		header := fmt.Appendf(nil, "%s%d:%d:", slidingHeader, max(idle.Milliseconds(), 1), deadline)
+		data = append(header, data...)
+		if lifetime <= 0 || idle < lifetime {
+			ttl = idle
+		}
+	}
+
+	args := []any{c.rawKey(key), data}
+	if ttl > 0 {
+		args = append(args, "PX", max(ttl.Milliseconds(), 1))
+	}
+
+	// Get a connection from the pool
+	conn := c.redis.ConnPool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", args...)
+	return err
+}
+
+// slidingValue returns the JSON encoded value of data, skipping the sliding
+// header if there is one.
+func slidingValue(data []byte) []byte {
+	rest, ok := bytes.CutPrefix(data, []byte(slidingHeader))
+	if !ok {
+		return data
+	}
+
+	// Skip the idle time and the deadline
+	for range 2 {
+		if _, rest, ok = bytes.Cut(rest, []byte(":")); !ok {
+			return data
+		}
+	}
+
+	return rest
 }
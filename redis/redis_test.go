@@ -1,9 +1,18 @@
 package redis
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+	"github.com/sk-pkg/cache/stats"
+	"github.com/sk-pkg/redis"
 )
 
 func TestRedisCache(t *testing.T) {
@@ -119,6 +128,27 @@ func TestRedisCache(t *testing.T) {
 		t.Error("decrement failed")
 	}
 
+	keys, err := c.Keys("e*")
+	if err != nil || !slices.Equal(keys, []string{"e"}) {
+		t.Error("Expected Keys to return the key without its prefix, got:", keys, err)
+	}
+
+	var scanned []string
+	var cursor uint64
+	for {
+		found, next, err := c.Scan(cursor, "", 10)
+		if err != nil {
+			t.Fatal("Scan failed:", err)
+		}
+		scanned = append(scanned, found...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if !slices.Contains(scanned, "e") {
+		t.Error("Expected Scan to find the key, got:", scanned)
+	}
+
 	err = c.Flush()
 	if err != nil {
 		return
@@ -129,3 +159,456 @@ func TestRedisCache(t *testing.T) {
 		t.Error("Got a failed value from mem cache:", e)
 	}
 }
+
+func TestClose(t *testing.T) {
+	// Connections are only dialed on use, so no server is needed
+	owned, _ := Init(WithRedisConfig(Config{Address: "127.0.0.1:1"}))
+	if err := owned.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+	if err := owned.Close(); err != nil {
+		t.Error("Closing twice should be a no-op, got:", err)
+	}
+	if err := owned.redis.ConnPool.Get().Err(); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Error("Expected the pool created from the config to be closed, got:", err)
+	}
+
+	checks := map[string]error{
+		"Put":          owned.Put("a", 1, 0),
+		"Add":          owned.Add("a", 1, 0),
+		"Forever":      owned.Forever("a", 1),
+		"Flush":        owned.Flush(),
+		"Ping":         owned.Ping(),
+		"Increment":    func() error { _, err := owned.Increment("n", 1); return err }(),
+		"Decrement":    func() error { _, err := owned.Decrement("n", 1); return err }(),
+		"Get":          func() error { _, err := owned.Get("a"); return err }(),
+		"Pull":         func() error { _, err := owned.Pull("a"); return err }(),
+		"Forget":       func() error { _, err := owned.Forget("a"); return err }(),
+		"Exists":       func() error { _, err := owned.Exists("a"); return err }(),
+		"Keys":         func() error { _, err := owned.Keys("*"); return err }(),
+		"Scan":         func() error { _, _, err := owned.Scan(0, "*", 0); return err }(),
+		"ForgetPrefix": func() error { _, err := owned.ForgetPrefix("a"); return err }(),
+		"Usage":        func() error { _, err := owned.Usage(); return err }(),
+	}
+	for op, err := range checks {
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected %s to return ErrClosed, got: %v", op, err)
+		}
+	}
+	if owned.Has("a") {
+		t.Error("Has should report false after Close")
+	}
+
+	// A manager given by the caller stays open
+	m := redis.New(redis.WithAddress("127.0.0.1:1"))
+	shared, _ := Init(WithRedisManager(m))
+	_ = shared.Close()
+	if err := m.ConnPool.Get().Err(); err != nil && strings.Contains(err.Error(), "closed") {
+		t.Error("Expected the manager given by the caller to stay open, got:", err)
+	}
+}
+
+// scanConn is a connection answering SCAN commands from a fixed key space,
+// two keys per step regardless of MATCH, recording UNLINK commands and
+// answering DBSIZE and MEMORY USAGE.
+type scanConn struct {
+	keys     []string
+	matches  []string   // The MATCH argument of every SCAN
+	counts   []int      // The COUNT argument of every SCAN, 0 if missing
+	unlinked [][]string // The keys of every UNLINK
+}
+
+func (c *scanConn) Do(cmd string, args ...any) (any, error) {
+	switch cmd {
+	case "UNLINK":
+		keys := make([]string, len(args))
+		for i, k := range args {
+			keys[i] = k.(string)
+		}
+		c.unlinked = append(c.unlinked, keys)
+		return int64(len(keys)), nil
+	case "DBSIZE":
+		return int64(len(c.keys)), nil
+	case "MEMORY":
+		// Every key takes 10 bytes per byte of its name
+		return int64(10 * len(args[1].(string))), nil
+	case "SCAN":
+	default:
+		return nil, fmt.Errorf("unexpected command %s", cmd)
+	}
+
+	cursor := int(args[0].(uint64))
+	match, count := "", 0
+	for i := 1; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "MATCH":
+			match = args[i+1].(string)
+		case "COUNT":
+			count = args[i+1].(int)
+		}
+	}
+	c.matches = append(c.matches, match)
+	c.counts = append(c.counts, count)
+
+	end := min(cursor+2, len(c.keys))
+	keys := make([]any, 0, 2)
+	for _, k := range c.keys[cursor:end] {
+		keys = append(keys, []byte(k))
+	}
+	next := end
+	if next == len(c.keys) {
+		next = 0
+	}
+	return []any{[]byte(fmt.Sprint(next)), keys}, nil
+}
+
+func (c *scanConn) Close() error              { return nil }
+func (c *scanConn) Err() error                { return nil }
+func (c *scanConn) Send(string, ...any) error { return nil }
+func (c *scanConn) Flush() error              { return nil }
+func (c *scanConn) Receive() (any, error)     { return nil, nil }
+
+func TestKeys(t *testing.T) {
+	conn := &scanConn{keys: []string{"app:c*:user:1", "app:c*:user:2", "app:c*:user:3"}}
+	m := &redis.Manager{
+		ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+		Prefix:   "app:",
+	}
+	c, _ := Init(WithRedisManager(m), WithPrefix("c*:"))
+
+	keys, err := c.Keys("user:*")
+	if err != nil {
+		t.Fatal("Keys failed:", err)
+	}
+	if !slices.Equal(keys, []string{"user:1", "user:2", "user:3"}) {
+		t.Error("Expected the keys without their prefix, got:", keys)
+	}
+	// The prefix is escaped so that only the pattern is a glob
+	if len(conn.matches) != 2 || conn.matches[0] != `app:c\*:user:*` {
+		t.Error("Expected two SCAN steps matching under the escaped prefix, got:", conn.matches)
+	}
+
+	conn.matches = nil
+	keys, next, err := c.Scan(0, "", 0)
+	if err != nil || next != 2 || !slices.Equal(keys, []string{"user:1", "user:2"}) {
+		t.Errorf("Expected the first step to return 2 keys and cursor 2, got %v, %d, %v", keys, next, err)
+	}
+	if conn.matches[0] != `app:c\*:*` {
+		t.Error("Expected an empty pattern to match every key, got:", conn.matches[0])
+	}
+}
+
+func TestForgetPattern(t *testing.T) {
+	conn := &scanConn{keys: []string{"app:user:42:a", "app:user:42:b", "app:user:42:c"}}
+	m := &redis.Manager{
+		ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+		Prefix:   "app:",
+	}
+	c, _ := Init(WithRedisManager(m))
+
+	removed, err := c.ForgetPrefix("user:42:")
+	if err != nil || removed != 3 {
+		t.Errorf("Expected ForgetPrefix to remove 3 keys, got %d, %v", removed, err)
+	}
+	// Every SCAN step is unlinked as a batch of full keys
+	want := [][]string{{"app:user:42:a", "app:user:42:b"}, {"app:user:42:c"}}
+	if !slices.EqualFunc(conn.unlinked, want, slices.Equal) {
+		t.Error("Expected two UNLINK batches, got:", conn.unlinked)
+	}
+	if conn.matches[0] != "app:user:42:*" {
+		t.Error("Expected SCAN to match the prefix, got:", conn.matches[0])
+	}
+	if s := c.Stats(); s.Deletes != 3 {
+		t.Error("Expected 3 deletes in the statistics, got:", s.Deletes)
+	}
+
+	conn.matches = nil
+	_, _ = c.ForgetPattern("user:*:[ab]")
+	if conn.matches[0] != "app:user:*:[ab]" {
+		t.Error("Expected SCAN to match the pattern under the prefix, got:", conn.matches[0])
+	}
+}
+
+func TestFlushContext(t *testing.T) {
+	newCache := func(opts ...Option) (Cache, *scanConn) {
+		conn := &scanConn{keys: []string{"app:a", "app:b", "app:c", "app:d", "app:e", "app:f"}}
+		m := &redis.Manager{
+			ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+			Prefix:   "app:",
+		}
+		c, _ := Init(append([]Option{WithRedisManager(m)}, opts...)...)
+		return *c, conn
+	}
+
+	var progress []int
+	c, conn := newCache(WithBatchSize(2), WithDeleteProgress(func(removed int) {
+		progress = append(progress, removed)
+	}))
+	removed, err := c.FlushContext(context.Background())
+	if err != nil || removed != 6 {
+		t.Errorf("Expected FlushContext to remove 6 keys, got %d, %v", removed, err)
+	}
+	if !slices.Equal(progress, []int{2, 4, 6}) {
+		t.Error("Expected a progress report per batch, got:", progress)
+	}
+	if !slices.Equal(conn.counts, []int{2, 2, 2}) || conn.matches[0] != "app:*" {
+		t.Errorf("Expected SCAN app:* COUNT 2, got %v COUNT %v", conn.matches, conn.counts)
+	}
+	if s := c.Stats(); s.Deletes != 6 || s.Ops[stats.OpFlush] != 1 {
+		t.Errorf("Expected 6 deletes and 1 flush, got %d and %d", s.Deletes, s.Ops[stats.OpFlush])
+	}
+
+	// Cancelling stops the flush before the next batch
+	ctx, cancel := context.WithCancel(context.Background())
+	c, conn = newCache(WithDeleteProgress(func(int) { cancel() }))
+	removed, err = c.FlushContext(ctx)
+	if !errors.Is(err, context.Canceled) || removed != 2 || len(conn.unlinked) != 1 {
+		t.Errorf("Expected the flush to stop after one batch, got %d keys, %d batches, %v", removed, len(conn.unlinked), err)
+	}
+
+	// 100 keys per second spaces the batches of 2 keys by 20ms
+	c, _ = newCache(WithDeleteRate(100))
+	start := time.Now()
+	if removed, _ := c.FlushContext(context.Background()); removed != 6 {
+		t.Error("Expected the rate limited flush to remove 6 keys, got:", removed)
+	}
+	if d := time.Since(start); d < 35*time.Millisecond {
+		t.Error("Expected the rate limit to slow the flush down, took:", d)
+	}
+
+	// A deadline also interrupts the wait between batches
+	c, _ = newCache(WithDeleteRate(1))
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if removed, err := c.FlushContext(ctx); !errors.Is(err, context.DeadlineExceeded) || removed != 2 {
+		t.Errorf("Expected the deadline to stop the flush after one batch, got %d, %v", removed, err)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	keys := []string{"app:a", "app:bb", "other:1", "other:2", "app:c", "other:3", "other:4", "other:5"}
+	newCache := func(opts ...Option) Cache {
+		conn := &scanConn{keys: keys}
+		m := &redis.Manager{
+			ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+			Prefix:   "app:",
+		}
+		c, _ := Init(append([]Option{WithRedisManager(m)}, opts...)...)
+		return *c
+	}
+
+	// A sample covering the whole keyspace counts exactly
+	u, err := newCache().Usage()
+	if err != nil {
+		t.Fatal("Usage failed:", err)
+	}
+	if u.Items != 3 || u.Sampled || u.Bytes != 10*(5+6+5) {
+		t.Errorf("Expected 3 keys of 160 bytes, got %+v", u)
+	}
+
+	// Two keys under the prefix among the first four extrapolate to half of DBSIZE
+	u, _ = newCache(WithUsageSample(4)).Usage()
+	if u.Items != 4 || !u.Sampled || u.Bytes != 4*(10*(5+6)/2) {
+		t.Errorf("Expected an estimate of 4 keys of 220 bytes, got %+v", u)
+	}
+
+	if n, err := newCache().Len(); err != nil || n != 3 {
+		t.Errorf("Expected Len to return 3, got %d, %v", n, err)
+	}
+}
+
+// slidingConn is a connection storing values in memory, answering SET (with NX), GET, DEL, INCRBY, DECRBY, GETRANGE
+// and the sliding script, whose PEXPIREAT it emulates.
+type slidingConn struct {
+	values  map[string][]byte
+	ttls    map[string]int64 // The PX argument of the last SET, or the PEXPIREAT delay from now
+	scripts int              // The number of EVAL commands
+}
+
+func (c *slidingConn) Do(cmd string, args ...any) (any, error) {
+	switch cmd {
+	case "SET":
+		key := args[0].(string)
+		if slices.Contains(args[2:], any("NX")) {
+			if _, ok := c.values[key]; ok {
+				return nil, nil
+			}
+		}
+		c.values[key] = args[1].([]byte)
+		c.ttls[key] = 0
+		if i := slices.Index(args, any("PX")); i > 0 {
+			c.ttls[key] = args[i+1].(int64)
+		}
+		return "OK", nil
+	case "GET":
+		if v, ok := c.values[args[0].(string)]; ok {
+			return v, nil
+		}
+		return nil, nil
+	case "DEL":
+		delete(c.values, args[0].(string))
+		return int64(1), nil
+	case "INCRBY", "DECRBY":
+		key, by := args[0].(string), args[1].(int)
+		if cmd == "DECRBY" {
+			by = -by
+		}
+		n, err := strconv.Atoi(string(c.values[key]))
+		if _, ok := c.values[key]; ok && err != nil {
+			return nil, redigo.Error("ERR value is not an integer or out of range")
+		}
+		c.values[key] = []byte(strconv.Itoa(n + by))
+		return int64(n + by), nil
+	case "GETRANGE":
+		v := c.values[args[0].(string)]
+		return v[:min(len(v), args[2].(int)+1)], nil
+	case "EVALSHA":
+		return nil, redigo.Error("NOSCRIPT No matching script")
+	case "EVAL":
+		c.scripts++
+		key, now := args[2].(string), args[3].(int64)
+		v, ok := c.values[key]
+		if !ok {
+			return nil, nil
+		}
+		var idle, deadline int64
+		if _, err := fmt.Sscanf(strings.TrimPrefix(string(v), slidingHeader), "%d:%d:", &idle, &deadline); err == nil {
+			at := now + idle
+			if deadline > 0 && deadline < at {
+				at = deadline
+			}
+			c.ttls[key] = at - now
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unexpected command %s", cmd)
+	}
+}
+
+func (c *slidingConn) Close() error              { return nil }
+func (c *slidingConn) Err() error                { return nil }
+func (c *slidingConn) Send(string, ...any) error { return nil }
+func (c *slidingConn) Flush() error              { return nil }
+func (c *slidingConn) Receive() (any, error)     { return nil, nil }
+
+func TestPutSliding(t *testing.T) {
+	conn := &slidingConn{values: map[string][]byte{}, ttls: map[string]int64{}}
+	m := &redis.Manager{
+		ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+		Prefix:   "app:",
+	}
+	c, _ := Init(WithRedisManager(m), WithPrefix("s:"))
+
+	if err := c.PutSliding("session", map[string]any{"user": "ann"}, 30*time.Minute); err != nil {
+		t.Fatal("PutSliding failed:", err)
+	}
+	if got := string(conn.values["app:s:session"]); got != `~sliding:1800000:0:{"user":"ann"}` {
+		t.Error("Expected the value behind the sliding header, got:", got)
+	}
+	if ttl := conn.ttls["app:s:session"]; ttl != 1_800_000 {
+		t.Error("Expected the idle time as TTL, got:", ttl)
+	}
+
+	// Get decodes the value and extends it with the script
+	conn.ttls["app:s:session"] = 1000
+	v, err := c.Get("session")
+	if m, ok := v.(map[string]any); err != nil || !ok || m["user"] != "ann" {
+		t.Errorf("Expected the decoded session, got %v, %v", v, err)
+	}
+	if conn.scripts != 1 || conn.ttls["app:s:session"] != 1_800_000 {
+		t.Errorf("Expected one script extending the session, got %d scripts and TTL %d", conn.scripts, conn.ttls["app:s:session"])
+	}
+
+	// The lifetime caps the TTL and the extensions
+	_ = c.PutSlidingWithLifetime("short", "v", time.Minute, 10*time.Second)
+	if ttl := conn.ttls["app:s:short"]; ttl != 10_000 {
+		t.Error("Expected the lifetime as TTL, got:", ttl)
+	}
+	if _, err := c.Get("short"); err != nil || conn.ttls["app:s:short"] > 10_000 {
+		t.Errorf("Expected the extension to stop at the deadline, got TTL %d, %v", conn.ttls["app:s:short"], err)
+	}
+
+	// Every Get reads with the script, in a single round trip; Pull does not
+	conn.scripts = 0
+	_ = c.PutSlidingWithLifetime("fixed", "v", 0, time.Minute)
+	_ = c.Put("plain", "v", 60)
+	if got := string(conn.values["app:s:fixed"]); got != `"v"` || conn.ttls["app:s:fixed"] != 60_000 {
+		t.Errorf("Expected a plain value with the lifetime as TTL, got %s with TTL %d", got, conn.ttls["app:s:fixed"])
+	}
+	for _, key := range []string{"fixed", "plain"} {
+		if v, err := c.Get(key); v != "v" || err != nil {
+			t.Errorf("Get(%q) = %v, %v", key, v, err)
+		}
+	}
+	if conn.scripts != 2 || conn.ttls["app:s:fixed"] != 60_000 {
+		t.Errorf("Expected 2 scripts leaving plain values unchanged, got %d scripts and TTL %d", conn.scripts, conn.ttls["app:s:fixed"])
+	}
+	if _, err := c.Get("missing"); !IsMiss(err) {
+		t.Error("Expected a miss for a missing key, got:", err)
+	}
+	conn.scripts = 0
+	if v, _ := c.Pull("session"); v == nil {
+		t.Error("Expected Pull to return the session")
+	}
+	if conn.scripts != 0 {
+		t.Error("Expected no script for Pull, got:", conn.scripts)
+	}
+
+	// Sliding values cannot be incremented, other values still can. Both use
+	// the same key as the other commands, with the prefix of the manager
+	counters, _ := Init(WithRedisManager(m), WithPrefix("c:"))
+	_ = counters.PutSliding("hits", 1, time.Minute)
+	if _, err := counters.Increment("hits", 1); !errors.Is(err, ErrSlidingValue) {
+		t.Error("Expected Increment to return ErrSlidingValue, got:", err)
+	}
+	if _, err := counters.Decrement("hits", 1); !errors.Is(err, ErrSlidingValue) {
+		t.Error("Expected Decrement to return ErrSlidingValue, got:", err)
+	}
+	_ = counters.Put("visits", 1, 60)
+	if n, err := counters.Increment("visits", 2); n != 3 || err != nil {
+		t.Errorf("Expected plain values to be incremented, got %d, %v", n, err)
+	}
+	if got := string(conn.values["app:c:visits"]); got != "3" || len(conn.values["c:visits"]) != 0 {
+		t.Error("Expected Increment to update the prefixed key, got:", got)
+	}
+
+	_ = c.Close()
+	if err := c.PutSliding("session", "v", time.Minute); !errors.Is(err, ErrClosed) {
+		t.Error("Expected PutSliding to return ErrClosed, got:", err)
+	}
+}
+
+func TestAddWithJitter(t *testing.T) {
+	conn := &slidingConn{values: map[string][]byte{}, ttls: map[string]int64{}}
+	c, _ := Init(WithRedisManager(&redis.Manager{
+		ConnPool: &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }},
+		Prefix:   "app:",
+	}))
+
+	// The key is written with a single SET NX and the jittered TTL
+	if err := c.AddWithJitter("a", 1, 10, Jitter{Fraction: 0.5}); err != nil {
+		t.Fatal("AddWithJitter failed:", err)
+	}
+	if ttl := conn.ttls["app:a"]; string(conn.values["app:a"]) != "1" || ttl < 10_000 || ttl > 15_000 {
+		t.Errorf("Expected the value with a jittered TTL, got %s with TTL %d", conn.values["app:a"], ttl)
+	}
+
+	// An existing key is left alone
+	if err := c.Add("a", 2, 10); err != nil {
+		t.Fatal("Add failed:", err)
+	}
+	if got := string(conn.values["app:a"]); got != "1" {
+		t.Error("Expected Add to keep the existing value, got:", got)
+	}
+	if s := c.Stats(); s.Ops[stats.OpAdd] != 2 || s.Writes != 1 {
+		t.Errorf("Expected 2 adds and 1 write, got %d and %d", s.Ops[stats.OpAdd], s.Writes)
+	}
+
+	// Errors are returned instead of overwriting the key
+	if err := c.Add("b", func() {}, 10); err == nil {
+		t.Error("Expected the encoding error")
+	}
+	if _, ok := conn.values["app:b"]; ok {
+		t.Error("Expected no write after an error")
+	}
+}
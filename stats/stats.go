@@ -0,0 +1,418 @@
+// Package stats provides lock-free counters and latency histograms used by the
+// cache drivers to report hits, misses, writes, deletes, errors and evictions.
+package stats
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op identifies a cache operation in latency histograms.
+type Op int
+
+// Operations of the cache interface.
+const (
+	OpGet Op = iota
+	OpPut
+	OpAdd
+	OpPull
+	OpHas
+	OpForever
+	OpForget
+	OpIncrement
+	OpDecrement
+	OpFlush
+	OpScan
+	OpForgetMatch
+	opCount
+)
+
+// opNames holds the names of all operations, indexed by Op.
+var opNames = [opCount]string{"get", "put", "add", "pull", "has", "forever", "forget", "increment", "decrement", "flush", "scan", "forget_match"}
+
+// String returns the lower-case name of the operation.
+func (o Op) String() string {
+	if o < 0 || o >= opCount {
+		return "unknown"
+	}
+	return opNames[o]
+}
+
+// Ops returns all operations in declaration order.
+func Ops() []Op {
+	ops := make([]Op, opCount)
+	for i := range ops {
+		ops[i] = Op(i)
+	}
+	return ops
+}
+
+// Outcome is a set of flags describing the result of an operation.
+type Outcome uint8
+
+const (
+	Hit    Outcome = 1 << iota // A lookup found a value
+	Miss                       // A lookup found nothing
+	Write                      // A value was stored
+	Delete                     // A value was removed on request
+	Error                      // The operation failed
+
+	// None records only the latency of an operation
+	None Outcome = 0
+)
+
+// buckets are the upper bounds of the latency histogram buckets.
+var buckets = [...]time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// Buckets returns the upper bounds of the latency histogram buckets.
+// Observations above the last bound are counted in an extra +Inf bucket.
+func Buckets() []time.Duration {
+	b := buckets
+	return b[:]
+}
+
+// histogram is a fixed-bucket latency histogram updated with atomic operations.
+type histogram struct {
+	counts [len(buckets) + 1]atomic.Uint64 // One counter per bucket, plus one for +Inf
+	sum    atomic.Int64                    // Total observed nanoseconds
+}
+
+// observe records a single latency.
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(buckets) && d > buckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Histogram is a point-in-time copy of a latency histogram.
+type Histogram struct {
+	Counts []uint64      // Observations per bucket (not cumulative), the last entry is +Inf
+	Count  uint64        // Total number of observations
+	Sum    time.Duration // Sum of all observed latencies
+}
+
+// Mean returns the average observed latency.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// merge adds the observations of o to h.
+func (h *Histogram) merge(o Histogram) {
+	if h.Counts == nil {
+		h.Counts = make([]uint64, len(o.Counts))
+	}
+	for i, n := range o.Counts {
+		h.Counts[i] += n
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
+
+// Snapshot is a point-in-time copy of the statistics of a store.
+type Snapshot struct {
+	Hits        uint64              // Lookups that found a value
+	Misses      uint64              // Lookups that found nothing
+	Writes      uint64              // Values stored
+	Deletes     uint64              // Values removed on request
+	Errors      uint64              // Operations that failed
+	Evictions   uint64              // Values removed to make room for others
+	Expirations uint64              // Values removed because their TTL elapsed
+	Ops         map[Op]uint64       // Number of calls per operation
+	Latency     map[Op]Histogram    // Latency per operation (possibly sampled)
+	Prefixes    map[string]Snapshot // Statistics per key prefix, nil unless enabled
+	Usage       Usage               // Items and bytes held, only filled in by the memory cache
+}
+
+// Usage describes how much a store holds.
+type Usage struct {
+	Items   int64        // Items stored and not expired
+	Expired int64        // Items that expired but were not removed yet (memory cache only)
+	Bytes   int64        // Estimated bytes held by the items, including expired ones
+	Sampled bool         // Whether Items or Bytes were extrapolated from a sample
+	Shards  []ShardUsage // Usage of every shard of the memory cache, nil otherwise
+}
+
+// ShardUsage describes how much a shard of the memory cache holds.
+type ShardUsage struct {
+	Items   int64 // Items stored and not expired
+	Expired int64 // Items that expired but were not removed yet
+	Bytes   int64 // Estimated bytes held by the items
+}
+
+// Add adds the usage of a shard to u.
+func (u *Usage) Add(s ShardUsage) {
+	u.Items += s.Items
+	u.Expired += s.Expired
+	u.Bytes += s.Bytes
+	u.Shards = append(u.Shards, s)
+}
+
+// HitRatio returns hits / (hits + misses), or 0 when nothing was looked up.
+func (s Snapshot) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Merge adds the counters of o to s. It is used to combine per-shard snapshots.
+//
+// Example:
+//
+//	var total stats.Snapshot
+//	for _, shard := range shards {
+//	    total.Merge(shard.Snapshot())
+//	}
+func (s *Snapshot) Merge(o Snapshot) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Writes += o.Writes
+	s.Deletes += o.Deletes
+	s.Errors += o.Errors
+	s.Evictions += o.Evictions
+	s.Expirations += o.Expirations
+
+	for op, n := range o.Ops {
+		if s.Ops == nil {
+			s.Ops = make(map[Op]uint64, len(o.Ops))
+		}
+		s.Ops[op] += n
+	}
+
+	for op, h := range o.Latency {
+		if s.Latency == nil {
+			s.Latency = make(map[Op]Histogram, len(o.Latency))
+		}
+		merged := s.Latency[op]
+		merged.merge(h)
+		s.Latency[op] = merged
+	}
+
+	s.Usage.Sampled = s.Usage.Sampled || o.Usage.Sampled
+	if o.Usage.Shards == nil {
+		s.Usage.Items += o.Usage.Items
+		s.Usage.Expired += o.Usage.Expired
+		s.Usage.Bytes += o.Usage.Bytes
+	}
+	for _, shard := range o.Usage.Shards {
+		s.Usage.Add(shard)
+	}
+
+	for prefix, p := range o.Prefixes {
+		if s.Prefixes == nil {
+			s.Prefixes = make(map[string]Snapshot, len(o.Prefixes))
+		}
+		merged := s.Prefixes[prefix]
+		merged.Merge(p)
+		s.Prefixes[prefix] = merged
+	}
+}
+
+// Recorder collects the statistics of a store (or a shard of it).
+// All methods are safe for concurrent use and never block.
+type Recorder struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	writes      atomic.Uint64
+	deletes     atomic.Uint64
+	errors      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	ops         [opCount]atomic.Uint64
+	latency     [opCount]histogram
+}
+
+// Record records the outcome and latency of an operation.
+// A nil *Recorder is valid and records nothing.
+//
+// Example:
+//
+//	start := time.Now()
+//	value, ok := lookup(key)
+//	if ok {
+//	    r.Record(stats.OpGet, time.Since(start), stats.Hit)
+//	}
+func (r *Recorder) Record(op Op, d time.Duration, o Outcome) {
+	r.Count(op, o)
+	r.Observe(op, d)
+}
+
+// Count records an operation and its outcome without its latency.
+// It is used together with Observe when latencies are sampled.
+func (r *Recorder) Count(op Op, o Outcome) {
+	if r == nil {
+		return
+	}
+
+	r.ops[op].Add(1)
+
+	if o&Hit != 0 {
+		r.hits.Add(1)
+	}
+	if o&Miss != 0 {
+		r.misses.Add(1)
+	}
+	if o&Write != 0 {
+		r.writes.Add(1)
+	}
+	if o&Delete != 0 {
+		r.deletes.Add(1)
+	}
+	if o&Error != 0 {
+		r.errors.Add(1)
+	}
+}
+
+// Observe records the latency of an operation.
+func (r *Recorder) Observe(op Op, d time.Duration) {
+	if r != nil {
+		r.latency[op].observe(d)
+	}
+}
+
+// Delete records n values removed on request outside of a single-key operation, e.g. by Flush.
+func (r *Recorder) Delete(n int) {
+	if r != nil {
+		r.deletes.Add(uint64(n))
+	}
+}
+
+// Evict records n values removed to make room for others.
+func (r *Recorder) Evict(n int) {
+	if r != nil {
+		r.evictions.Add(uint64(n))
+	}
+}
+
+// Expire records n values removed because their TTL elapsed.
+func (r *Recorder) Expire(n int) {
+	if r != nil {
+		r.expirations.Add(uint64(n))
+	}
+}
+
+// Snapshot returns a copy of the current statistics.
+func (r *Recorder) Snapshot() Snapshot {
+	s := Snapshot{
+		Hits:        r.hits.Load(),
+		Misses:      r.misses.Load(),
+		Writes:      r.writes.Load(),
+		Deletes:     r.deletes.Load(),
+		Errors:      r.errors.Load(),
+		Evictions:   r.evictions.Load(),
+		Expirations: r.expirations.Load(),
+		Ops:         make(map[Op]uint64, opCount),
+		Latency:     make(map[Op]Histogram, opCount),
+	}
+
+	for op := range r.ops {
+		if n := r.ops[op].Load(); n > 0 {
+			s.Ops[Op(op)] = n
+		}
+	}
+
+	for op := range r.latency {
+		h := &r.latency[op]
+		hs := Histogram{Counts: make([]uint64, len(h.counts)), Sum: time.Duration(h.sum.Load())}
+		for i := range h.counts {
+			hs.Counts[i] = h.counts[i].Load()
+			hs.Count += hs.Counts[i]
+		}
+		if hs.Count > 0 {
+			s.Latency[Op(op)] = hs
+		}
+	}
+
+	return s
+}
+
+// PrefixFunc extracts the statistics group of a key, e.g. "user" for "user:42".
+// Returning an empty string excludes the key from per-prefix statistics.
+type PrefixFunc func(key string) string
+
+// PrefixUntil returns a PrefixFunc that groups keys by the text before the first sep.
+// Keys without sep are grouped under the empty prefix and therefore not tracked.
+//
+// Example:
+//
+//	f := stats.PrefixUntil(":")
+//	f("user:42:profile") // "user"
+func PrefixUntil(sep string) PrefixFunc {
+	return func(key string) string {
+		prefix, _, found := strings.Cut(key, sep)
+		if !found {
+			return ""
+		}
+		return prefix
+	}
+}
+
+// Prefixes keeps one Recorder per key prefix.
+type Prefixes struct {
+	fn        PrefixFunc
+	recorders sync.Map // prefix -> *Recorder
+}
+
+// NewPrefixes creates per-prefix statistics grouped by fn.
+func NewPrefixes(fn PrefixFunc) *Prefixes {
+	return &Prefixes{fn: fn}
+}
+
+// For returns the Recorder of the prefix of key, or nil if the key is not tracked.
+// A nil *Prefixes is valid and tracks nothing.
+func (p *Prefixes) For(key string) *Recorder {
+	if p == nil {
+		return nil
+	}
+
+	prefix := p.fn(key)
+	if prefix == "" {
+		return nil
+	}
+
+	if r, ok := p.recorders.Load(prefix); ok {
+		return r.(*Recorder)
+	}
+
+	r, _ := p.recorders.LoadOrStore(prefix, &Recorder{})
+	return r.(*Recorder)
+}
+
+// Snapshot returns a copy of the statistics of every tracked prefix.
+// It returns nil for a nil *Prefixes.
+func (p *Prefixes) Snapshot() map[string]Snapshot {
+	if p == nil {
+		return nil
+	}
+
+	m := make(map[string]Snapshot)
+	p.recorders.Range(func(k, v any) bool {
+		m[k.(string)] = v.(*Recorder).Snapshot()
+		return true
+	})
+
+	return m
+}
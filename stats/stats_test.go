@@ -0,0 +1,98 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	var r Recorder
+
+	r.Record(OpGet, 2*time.Microsecond, Hit)
+	r.Record(OpGet, 2*time.Second, Miss)
+	r.Record(OpPull, time.Microsecond, Hit|Delete)
+	r.Record(OpPut, time.Microsecond, Write)
+	r.Record(OpDecrement, time.Microsecond, Error)
+	r.Evict(2)
+	r.Expire(3)
+
+	s := r.Snapshot()
+	if s.Hits != 2 || s.Misses != 1 || s.Writes != 1 || s.Deletes != 1 || s.Errors != 1 {
+		t.Error("Unexpected counters:", s)
+	}
+
+	if s.Ops[OpGet] != 2 || s.Ops[OpPut] != 1 {
+		t.Error("Unexpected operation counts:", s.Ops)
+	}
+
+	if s.Evictions != 2 || s.Expirations != 3 {
+		t.Error("Unexpected eviction counters:", s.Evictions, s.Expirations)
+	}
+
+	get := s.Latency[OpGet]
+	if get.Count != 2 || get.Counts[1] != 1 || get.Counts[len(get.Counts)-1] != 1 {
+		t.Error("Unexpected get histogram:", get)
+	}
+
+	if _, ok := s.Latency[OpFlush]; ok {
+		t.Error("Operations without observations should be omitted")
+	}
+
+	if ratio := s.HitRatio(); ratio < 0.66 || ratio > 0.67 {
+		t.Error("Unexpected hit ratio:", ratio)
+	}
+
+	// A nil recorder must be usable
+	var nilRecorder *Recorder
+	nilRecorder.Record(OpGet, time.Millisecond, Hit)
+	nilRecorder.Evict(1)
+}
+
+func TestSnapshotMerge(t *testing.T) {
+	var a, b Recorder
+	a.Record(OpGet, time.Microsecond, Hit)
+	b.Record(OpGet, time.Millisecond, Miss)
+
+	var total Snapshot
+	total.Merge(a.Snapshot())
+	total.Merge(b.Snapshot())
+
+	if total.Hits != 1 || total.Misses != 1 {
+		t.Error("Unexpected merged counters:", total)
+	}
+
+	if h := total.Latency[OpGet]; h.Count != 2 || h.Sum != time.Millisecond+time.Microsecond {
+		t.Error("Unexpected merged histogram:", h)
+	}
+	// Usage adds up, keeping the shards of both sides
+	var mem Usage
+	mem.Add(ShardUsage{Items: 2, Expired: 1, Bytes: 100})
+	mem.Add(ShardUsage{Items: 3, Bytes: 50})
+	total.Merge(Snapshot{Usage: mem})
+	total.Merge(Snapshot{Usage: Usage{Items: 10, Bytes: 1000, Sampled: true}})
+	if u := total.Usage; u.Items != 15 || u.Expired != 1 || u.Bytes != 1150 || !u.Sampled || len(u.Shards) != 2 {
+		t.Error("Unexpected merged usage:", u)
+	}
+}
+
+func TestPrefixes(t *testing.T) {
+	p := NewPrefixes(PrefixUntil(":"))
+
+	p.For("user:1").Record(OpGet, time.Microsecond, Hit)
+	p.For("user:2").Record(OpGet, time.Microsecond, Miss)
+	p.For("plain").Record(OpGet, time.Microsecond, Hit)
+
+	s := p.Snapshot()
+	if len(s) != 1 {
+		t.Fatal("Only keys with a prefix should be tracked:", s)
+	}
+
+	if user := s["user"]; user.Hits != 1 || user.Misses != 1 {
+		t.Error("Unexpected user prefix stats:", user)
+	}
+
+	var nilPrefixes *Prefixes
+	if nilPrefixes.For("user:1") != nil || nilPrefixes.Snapshot() != nil {
+		t.Error("A nil *Prefixes should track nothing")
+	}
+}
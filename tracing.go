@@ -0,0 +1,354 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/sk-pkg/cache/internal/glob"
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/redis"
+	"github.com/sk-pkg/cache/stats"
+)
+
+// SpanInfo describes the cache operation a span is started for.
+type SpanInfo struct {
+	Operation stats.Op // The cache operation, e.g. stats.OpGet
+	Store     string   // The cache driver, e.g. MemCache or RedisCache
+	KeyPrefix string   // The key up to the first ":" (the full key is never exposed), empty for Flush, Keys and Scan
+}
+
+// Tracer starts a span for every cache operation.
+// Implementations adapt it to a tracing library such as OpenTelemetry.
+type Tracer interface {
+	// Start is called before the operation runs. ctx is the context bound with
+	// Manager.WithContext (context.Background() otherwise), so the span can be
+	// attached to the caller's trace. The returned context carries the span; it
+	// is bound to the wrapped cache when it implements WithContext, so that its
+	// spans, e.g. those of a traced middleware below, become children of this one.
+	Start(ctx context.Context, info SpanInfo) (context.Context, Span)
+}
+
+// Span is a cache operation in flight.
+type Span interface {
+	// End is called after the operation completed. hit is only meaningful for
+	// lookups (Get, Pull and Has); err is nil on success and on cache misses.
+	End(hit bool, err error)
+}
+
+// NopTracer is a Tracer that does nothing. It is the default tracer.
+type NopTracer struct{}
+
+// Start returns ctx and a span that does nothing.
+func (NopTracer) Start(ctx context.Context, _ SpanInfo) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+// nopSpan is the span returned by NopTracer.
+type nopSpan struct{}
+
+// End does nothing.
+func (nopSpan) End(bool, error) {}
+
+// contextBinder is implemented by caches that can be bound to a context.
+type contextBinder interface {
+	WithContext(ctx context.Context) Cache
+}
+
+// Traced wraps c so that every call is reported to t as a span.
+// Use WithContext on the result to attach spans to a parent context.
+//
+// Parameters:
+//   - c: The cache to trace
+//   - store: The driver name reported in SpanInfo.Store
+//   - t: The tracer receiving the spans
+//
+// Returns:
+//   - *TracedCache: The traced cache
+//
+// Example:
+//
+//	traced := cache.Traced(c.Mem, cache.MemCache, myTracer)
+//	value, err := traced.WithContext(ctx).Get("user:1")
+func Traced(c Cache, store string, t Tracer) *TracedCache {
+	return &TracedCache{next: c, store: store, tracer: t, ctx: context.Background()}
+}
+
+// TracedCache is a Cache that reports every call to a Tracer.
+type TracedCache struct {
+	next   Cache
+	store  string
+	tracer Tracer
+	ctx    context.Context
+	log    *logx.Logger // Writes the errors swallowed by Has, set by New from WithLogger
+}
+
+// WithContext returns a copy of the cache whose spans are started with ctx.
+func (c *TracedCache) WithContext(ctx context.Context) Cache {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+// start starts the span of an operation on key. It returns the wrapped cache
+// bound to the context of the span when it can be bound, so that the spans of
+// the wrapped cache become its children.
+func (c *TracedCache) start(op stats.Op, key string) (Cache, Span) {
+	prefix, _, _ := strings.Cut(key, ":")
+	ctx, span := c.tracer.Start(c.ctx, SpanInfo{Operation: op, Store: c.store, KeyPrefix: prefix})
+
+	next := c.next
+	if cb, ok := next.(contextBinder); ok {
+		next = cb.WithContext(ctx)
+	}
+	return next, span
+}
+
+// endLookup ends the span of a lookup, reporting Redis misses as misses instead of errors.
+func endLookup(span Span, value any, err error) {
+	if redis.IsMiss(err) {
+		span.End(false, nil)
+		return
+	}
+	span.End(err == nil && value != nil, err)
+}
+
+// Put stores data and reports it as a span.
+func (c *TracedCache) Put(key string, value any, seconds int) error {
+	next, span := c.start(stats.OpPut, key)
+	err := next.Put(key, value, seconds)
+	span.End(false, err)
+	return err
+}
+
+// PutWithJitter stores data with the given TTL jitter and reports it as a span.
+func (c *TracedCache) PutWithJitter(key string, value any, seconds int, j Jitter) error {
+	next, span := c.start(stats.OpPut, key)
+	var err error
+	if jc, ok := next.(jitterCache); ok {
+		err = jc.PutWithJitter(key, value, seconds, j)
+	} else {
+		err = next.Put(key, value, seconds)
+	}
+	span.End(false, err)
+	return err
+}
+
+// PutSlidingWithLifetime stores data with a sliding expiration and reports it as a span.
+func (c *TracedCache) PutSlidingWithLifetime(key string, value any, idle, lifetime time.Duration) error {
+	next, span := c.start(stats.OpPut, key)
+	err := putSliding(next, key, value, idle, lifetime)
+	span.End(false, err)
+	return err
+}
+
+// Add stores data if the key does not exist and reports it as a span.
+func (c *TracedCache) Add(key string, value any, seconds int) error {
+	next, span := c.start(stats.OpAdd, key)
+	err := next.Add(key, value, seconds)
+	span.End(false, err)
+	return err
+}
+
+// AddWithJitter stores data if the key does not exist with the given TTL jitter
+// and reports it as a span.
+func (c *TracedCache) AddWithJitter(key string, value any, seconds int, j Jitter) error {
+	next, span := c.start(stats.OpAdd, key)
+	var err error
+	if jc, ok := next.(jitterCache); ok {
+		err = jc.AddWithJitter(key, value, seconds, j)
+	} else {
+		err = next.Add(key, value, seconds)
+	}
+	span.End(false, err)
+	return err
+}
+
+// Get retrieves data and reports it as a span.
+func (c *TracedCache) Get(key string) (any, error) {
+	next, span := c.start(stats.OpGet, key)
+	value, err := next.Get(key)
+	endLookup(span, value, err)
+	return value, err
+}
+
+// Pull retrieves and removes data and reports it as a span.
+func (c *TracedCache) Pull(key string) (any, error) {
+	next, span := c.start(stats.OpPull, key)
+	value, err := next.Pull(key)
+	endLookup(span, value, err)
+	return value, err
+}
+
+// Has checks if an item exists and reports it as a span. Lookup errors of
+// caches implementing Exists are reported to the span and logged, as Has
+// swallows them.
+func (c *TracedCache) Has(key string) bool {
+	next, span := c.start(stats.OpHas, key)
+	if ec, ok := next.(existsCache); ok {
+		exists, err := ec.Exists(key)
+		span.End(exists, err)
+		if err != nil {
+			c.log.Error("cache lookup failed", err,
+				slog.String("store", c.store),
+				slog.String("operation", stats.OpHas.String()),
+				slog.String("key", key),
+			)
+		}
+		return exists
+	}
+	exists := next.Has(key)
+	span.End(exists, nil)
+	return exists
+}
+
+// Forever stores data permanently and reports it as a span.
+func (c *TracedCache) Forever(key string, value any) error {
+	next, span := c.start(stats.OpForever, key)
+	err := next.Forever(key, value)
+	span.End(false, err)
+	return err
+}
+
+// Forget removes an item and reports it as a span.
+func (c *TracedCache) Forget(key string) (bool, error) {
+	next, span := c.start(stats.OpForget, key)
+	removed, err := next.Forget(key)
+	span.End(false, err)
+	return removed, err
+}
+
+// Increment increases an integer value and reports it as a span.
+func (c *TracedCache) Increment(key string, n int) (int, error) {
+	next, span := c.start(stats.OpIncrement, key)
+	value, err := next.Increment(key, n)
+	span.End(false, err)
+	return value, err
+}
+
+// Decrement decreases an integer value and reports it as a span.
+func (c *TracedCache) Decrement(key string, n int) (int, error) {
+	next, span := c.start(stats.OpDecrement, key)
+	value, err := next.Decrement(key, n)
+	span.End(false, err)
+	return value, err
+}
+
+// Flush removes all items and reports it as a span.
+func (c *TracedCache) Flush() error {
+	next, span := c.start(stats.OpFlush, "")
+	err := next.Flush()
+	span.End(false, err)
+	return err
+}
+
+// Keys returns the matching keys and reports it as a span.
+func (c *TracedCache) Keys(pattern string) ([]string, error) {
+	next, span := c.start(stats.OpScan, "")
+	keys, err := next.Keys(pattern)
+	span.End(false, err)
+	return keys, err
+}
+
+// Scan runs one step of a key iteration and reports it as a span.
+func (c *TracedCache) Scan(cursor uint64, pattern string, count int) ([]string, uint64, error) {
+	next, span := c.start(stats.OpScan, "")
+	keys, cursor, err := next.Scan(cursor, pattern, count)
+	span.End(false, err)
+	return keys, cursor, err
+}
+
+// ForgetPrefix removes the keys starting with prefix and reports it as a span.
+func (c *TracedCache) ForgetPrefix(prefix string) (int, error) {
+	next, span := c.start(stats.OpForgetMatch, prefix)
+	removed, err := forgetPrefix(next, prefix)
+	span.End(false, err)
+	return removed, err
+}
+
+// ForgetPattern removes the keys matching pattern and reports it as a span.
+func (c *TracedCache) ForgetPattern(pattern string) (int, error) {
+	next, span := c.start(stats.OpForgetMatch, glob.Prefix(pattern))
+	removed, err := forgetPattern(next, pattern)
+	span.End(false, err)
+	return removed, err
+}
+
+// Attribute is a key/value pair attached to a span by AttributeTracer.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attribute keys set by AttributeTracer, following the OpenTelemetry naming style.
+const (
+	AttrOperation = "cache.operation"  // Operation name, e.g. "get"
+	AttrStore     = "cache.store"      // Driver name, e.g. "redis"
+	AttrKeyPrefix = "cache.key_prefix" // Key up to the first ":"
+	AttrHit       = "cache.hit"        // Lookup result, only set for Get, Pull and Has
+)
+
+// SpanStarter is the subset of an OpenTelemetry-style tracer used by AttributeTracer.
+// A thin shim maps it onto go.opentelemetry.io/otel/trace.Tracer.
+type SpanStarter interface {
+	Start(ctx context.Context, name string) (context.Context, AttributeSpan)
+}
+
+// AttributeSpan is the subset of an OpenTelemetry-style span used by AttributeTracer.
+type AttributeSpan interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// AttributeTracer is a reference Tracer that names spans "cache.<operation>"
+// and describes them with the Attr* attributes.
+//
+// Example:
+//
+//	// otelStarter wraps an OpenTelemetry tracer
+//	type otelStarter struct{ t trace.Tracer }
+//
+//	func (s otelStarter) Start(ctx context.Context, name string) (context.Context, cache.AttributeSpan) {
+//	  ctx, span := s.t.Start(ctx, name)
+//	  return ctx, otelSpan{span}
+//	}
+//
+//	c, _ := cache.New(cache.WithTracer(cache.AttributeTracer{Starter: otelStarter{otel.Tracer("cache")}}))
+type AttributeTracer struct {
+	Starter SpanStarter
+}
+
+// Start starts a span named after the operation and sets its attributes. It
+// returns the context derived by the Starter, which carries the span.
+func (t AttributeTracer) Start(ctx context.Context, info SpanInfo) (context.Context, Span) {
+	ctx, span := t.Starter.Start(ctx, "cache."+info.Operation.String())
+	span.SetAttributes(
+		Attribute{Key: AttrOperation, Value: info.Operation.String()},
+		Attribute{Key: AttrStore, Value: info.Store},
+		Attribute{Key: AttrKeyPrefix, Value: info.KeyPrefix},
+	)
+
+	return ctx, attributeSpan{span: span, op: info.Operation}
+}
+
+// attributeSpan ends an AttributeSpan with the outcome of the operation.
+type attributeSpan struct {
+	span AttributeSpan
+	op   stats.Op
+}
+
+// End records the lookup result and error, then ends the span.
+func (s attributeSpan) End(hit bool, err error) {
+	switch s.op {
+	case stats.OpGet, stats.OpPull, stats.OpHas:
+		s.span.SetAttributes(Attribute{Key: AttrHit, Value: hit})
+	}
+
+	if err != nil {
+		s.span.RecordError(err)
+	}
+
+	s.span.End()
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sk-pkg/cache/internal/logx"
+	"github.com/sk-pkg/cache/mem"
+)
+
+// ctxKey is the type of the context key used to check context propagation.
+type ctxKey struct{}
+
+// recordedSpan is a span captured by spanRecorder.
+type recordedSpan struct {
+	name   string
+	parent any
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+// spanRecorder is an in-memory SpanStarter.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *spanRecorder) Start(ctx context.Context, name string) (context.Context, AttributeSpan) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	span := &recordedSpan{name: name, parent: ctx.Value(ctxKey{}), attrs: map[string]any{}}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, ctxKey{}, span), &recordingSpan{r: r, span: span}
+}
+
+// recordingSpan writes into a recordedSpan.
+type recordingSpan struct {
+	r    *spanRecorder
+	span *recordedSpan
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	for _, a := range attrs {
+		s.span.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.span.err = err
+}
+
+func (s *recordingSpan) End() {
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+	s.span.ended = true
+}
+
+func TestAttributeTracer(t *testing.T) {
+	rec := &spanRecorder{}
+	c, err := New(WithTracer(AttributeTracer{Starter: rec}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "parent")
+	bound := c.WithContext(ctx)
+
+	_ = bound.Put("user:1", "a", 10)
+	_, _ = bound.Get("user:1")
+	_, _ = c.Get("user:2")
+	_, _ = c.Decrement("order:1", 1)
+
+	if len(rec.spans) != 4 {
+		t.Fatal("Expected 4 spans, got:", len(rec.spans))
+	}
+
+	put, hit, miss, failed := rec.spans[0], rec.spans[1], rec.spans[2], rec.spans[3]
+
+	if put.name != "cache.put" || put.attrs[AttrStore] != MemCache || put.attrs[AttrKeyPrefix] != "user" {
+		t.Errorf("Unexpected put span: %+v", put)
+	}
+
+	if _, ok := put.attrs[AttrHit]; ok {
+		t.Error("Put spans should not have a hit attribute")
+	}
+
+	if put.parent != "parent" || hit.parent != "parent" {
+		t.Error("Spans of a bound cache should use the bound context")
+	}
+
+	if miss.parent != nil {
+		t.Error("Spans of the manager should use the background context")
+	}
+
+	if hit.attrs[AttrHit] != true || miss.attrs[AttrHit] != false {
+		t.Error("Unexpected hit attributes:", hit.attrs, miss.attrs)
+	}
+
+	if failed.err == nil || failed.attrs[AttrOperation] != "decrement" {
+		t.Errorf("Failed operations should record the error: %+v", failed)
+	}
+
+	for _, span := range rec.spans {
+		if !span.ended {
+			t.Error("Span should be ended:", span.name)
+		}
+	}
+}
+
+func TestNopTracer(t *testing.T) {
+	c, err := New(WithTracer(NopTracer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, traced := c.defaultCache.(*TracedCache); traced {
+		t.Error("The no-op tracer should not wrap the default cache")
+	}
+
+	// Binding a context without a tracer returns the default cache
+	if _, traced := c.WithContext(context.Background()).(*TracedCache); traced {
+		t.Error("WithContext should return the default cache without a tracer")
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	got, span := NopTracer{}.Start(ctx, SpanInfo{})
+	if got != ctx {
+		t.Error("The no-op tracer should return the context it was given")
+	}
+	span.End(false, errors.New("ignored"))
+}
+
+func TestTracingChildSpans(t *testing.T) {
+	rec := &spanRecorder{}
+	inner := Traced(mem.Init(), MemCache, AttributeTracer{Starter: rec})
+	outer := Traced(inner, MemCache, AttributeTracer{Starter: rec})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	_, _ = outer.WithContext(ctx).Get("user:1")
+	_ = outer.Put("user:2", 2, 0)
+
+	if len(rec.spans) != 4 {
+		t.Fatal("Expected a span per layer and call, got:", len(rec.spans))
+	}
+	if rec.spans[0].parent != "request" {
+		t.Error("Expected the outer span to be a child of the request, got:", rec.spans[0].parent)
+	}
+	if rec.spans[1].parent != rec.spans[0] {
+		t.Error("Expected the span of the wrapped cache to be a child of the outer span, got:", rec.spans[1].parent)
+	}
+	if rec.spans[2].parent != nil || rec.spans[3].parent != rec.spans[2] {
+		t.Error("Expected an unbound call to start a new trace with its own children")
+	}
+}
+
+func TestTracedHasLogging(t *testing.T) {
+	var out bytes.Buffer
+	primary := &existsFlakyCache{flakyCache: &flakyCache{Cache: mem.Init()}}
+	primary.down.Store(true)
+
+	traced := Traced(primary, RedisCache, NopTracer{})
+	traced.log = logx.New(slog.New(slog.NewTextHandler(&out, nil)), DefaultLogLevels(), 0)
+
+	// Has swallows the lookup error, so it is logged like the drivers do
+	if traced.Has("a") {
+		t.Error("Expected Has to report a missing key on error")
+	}
+	if !strings.Contains(out.String(), `msg="cache lookup failed" store=redis operation=has key=a`) {
+		t.Error("Expected the lookup error to be logged, got:", out.String())
+	}
+}